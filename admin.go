@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Bearer token required by the admin endpoints (ADMIN_TOKEN); empty disables them
+var adminToken string
+
+// Serializes reloads so two requests never swap pools concurrently
+var reloadMutex sync.Mutex
+
+// Admin authentication middleware
+func adminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			respondWithError(w, errCodeForbidden, "Admin API is disabled", http.StatusForbidden)
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			respondWithError(w, errCodeUnauthorized, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Reload all translation databases handler
+func adminReloadHandler(w http.ResponseWriter, r *http.Request) {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	log.Println("Reloading databases...")
+	if err := initDatabases(); err != nil {
+		// The previous databases stay in service when nothing could be loaded
+		log.Printf("Reload failed: %v", err)
+		respondWithError(w, errCodeInternal, "Reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The reload cleared the caches
+	if warmUpEnabled {
+		warmUpCaches()
+	}
+
+	dbMutex.RLock()
+	loaded := make([]string, 0, len(dbPool))
+	for name := range dbPool {
+		loaded = append(loaded, name)
+	}
+	dbMutex.RUnlock()
+	sort.Strings(loaded)
+
+	skipped := make([]string, 0)
+	for name := range translations {
+		if !containsString(loaded, name) {
+			skipped = append(skipped, name)
+		}
+	}
+	sort.Strings(skipped)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":       "reloaded",
+		"translations": loaded,
+		"unavailable":  skipped,
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
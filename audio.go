@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type AudioResponse struct {
+	Translation string   `json:"translation"`
+	BookNumber  int      `json:"book_number"`
+	Chapter     int      `json:"chapter"`
+	URLs        []string `json:"urls"`
+}
+
+// Optional table of per-chapter audio files: audio(book_number, chapter, url)
+const audioTable = "audio"
+
+// Get the audio file URLs of a chapter handler
+func audioHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	supported, err := hasTable(db, audioTable)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve audio", http.StatusInternalServerError)
+		return
+	}
+	if !supported {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("Translation '%s' has no audio", translationName), http.StatusNotFound)
+		return
+	}
+
+	rows, err := db.QueryContext(r.Context(), "SELECT url FROM audio WHERE book_number = ? AND chapter = ? ORDER BY rowid", ref.Book, ref.Chapter)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve audio", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	urls := make([]string, 0)
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve audio", http.StatusInternalServerError)
+			return
+		}
+		urls = append(urls, url)
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve audio", http.StatusInternalServerError)
+		return
+	}
+	if len(urls) == 0 {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("No audio for chapter %d:%d", ref.Book, ref.Chapter), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AudioResponse{
+		Translation: translationName,
+		BookNumber:  ref.Book,
+		Chapter:     ref.Chapter,
+		URLs:        urls,
+	})
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type BookInfo struct {
+	BookNumber int    `json:"book_number"`
+	ShortName  string `json:"short_name"`
+	LongName   string `json:"long_name"`
+}
+
+type BooksResponse struct {
+	Translation string     `json:"translation"`
+	Order       string     `json:"order"`
+	Books       []BookInfo `json:"books"`
+}
+
+type OutlineBook struct {
+	BookNumber int    `json:"book_number"`
+	ShortName  string `json:"short_name"`
+	LongName   string `json:"long_name"`
+	Chapters   int    `json:"chapters"`
+	Verses     *int   `json:"verses,omitempty"`
+}
+
+type BookNumbersResponse struct {
+	Translation string         `json:"translation"`
+	Books       map[string]int `json:"books"`
+}
+
+type OutlineResponse struct {
+	Translation string        `json:"translation"`
+	Books       []OutlineBook `json:"books"`
+}
+
+// Per-translation index of normalized book names to book_number
+var bookNames = make(map[string]map[string]int)
+
+// Returned by parseBook for names that match no book
+type bookNotFoundError struct {
+	name string
+}
+
+func (e *bookNotFoundError) Error() string {
+	return fmt.Sprintf("Book '%s' not found", e.name)
+}
+
+// Normalize a book name for lookup: case-insensitive, spaces ignored
+func normalizeBookName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// Build the name index of a translation from its books table
+func loadBookNames(db *sql.DB) (map[string]int, error) {
+	books, err := fetchBooks(db)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(books)*2)
+	for _, book := range books {
+		for _, name := range []string{book.ShortName, book.LongName} {
+			key := normalizeBookName(name)
+			if _, taken := index[key]; !taken {
+				index[key] = book.BookNumber
+			}
+		}
+	}
+	return index, nil
+}
+
+// Resolve a book path segment: either a book_number or a short/long name
+// (case-insensitive) of the given translation. Without a translation the
+// names of every loaded translation are searched.
+func parseBook(translationName, segment string) (int, error) {
+	if n, err := strconv.Atoi(segment); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("Invalid book number '%s'", segment)
+		}
+		return n, nil
+	}
+
+	key := normalizeBookName(segment)
+
+	dbMutex.RLock()
+	defer dbMutex.RUnlock()
+
+	if translationName != "" {
+		if book, ok := bookNames[translationName][key]; ok {
+			return book, nil
+		}
+	} else {
+		names := make([]string, 0, len(bookNames))
+		for name := range bookNames {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if book, ok := bookNames[name][key]; ok {
+				return book, nil
+			}
+		}
+	}
+	return 0, &bookNotFoundError{name: segment}
+}
+
+// Approximate order in which the canonical books were written, keyed by
+// MyBible book_number. Dating is disputed for many books; this follows a
+// common conservative scheme and is only meant for reading plans.
+var chronologicalBookOrder = []int{
+	// Old Testament
+	220, 10, 20, 30, 40, 50, 230, 60, 70, 80, 90, 100, 240, 250, 260,
+	110, 120, 380, 360, 390, 370, 350, 400, 290, 410, 430, 420, 300,
+	310, 330, 340, 440, 450, 190, 130, 140, 150, 160, 460,
+	// New Testament
+	660, 550, 590, 600, 530, 540, 520, 480, 470, 490, 510, 560, 580,
+	640, 570, 610, 630, 670, 620, 680, 650, 720, 500, 690, 700, 710, 730,
+}
+
+// MyBible numbers of the deuterocanonical and apocryphal books: 165 (1 Esdras),
+// 170-192 (Tobit, Judith, Greek Esther), 232 (Psalm 151), 270-280 (Wisdom,
+// Sirach), 305-345 (Azariah, Letter of Jeremiah, Baruch, Susanna, Bel),
+// 462-468 (1-4 Maccabees, 2 Esdras), 780-790 (Laodiceans, Prayer of Manasseh)
+var apocryphaBooks = map[int]bool{
+	165: true, 170: true, 180: true, 192: true, 232: true, 270: true, 280: true,
+	305: true, 315: true, 320: true, 325: true, 345: true,
+	462: true, 464: true, 466: true, 467: true, 468: true, 780: true, 790: true,
+}
+
+// Parse ?include_apocrypha (default false, for Protestant canon compatibility)
+func parseIncludeApocrypha(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("include_apocrypha")
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// Supported values for the books endpoint's order parameter
+const (
+	bookOrderCanonical     = "canonical"
+	bookOrderChronological = "chronological"
+)
+
+// List the books of a translation in canonical (book_number) order
+func fetchBooks(db *sql.DB) ([]BookInfo, error) {
+	rows, err := db.Query("SELECT book_number, short_name, long_name FROM books ORDER BY book_number")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var books []BookInfo
+	for rows.Next() {
+		var book BookInfo
+		if err := rows.Scan(&book.BookNumber, &book.ShortName, &book.LongName); err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	return books, rows.Err()
+}
+
+// Reorder books chronologically; books missing from the table keep canonical order at the end
+func sortBooksChronologically(books []BookInfo) []BookInfo {
+	rank := make(map[int]int, len(chronologicalBookOrder))
+	for i, number := range chronologicalBookOrder {
+		rank[number] = i
+	}
+
+	sorted := make([]BookInfo, len(books))
+	copy(sorted, books)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, iKnown := rank[sorted[i].BookNumber]
+		rj, jKnown := rank[sorted[j].BookNumber]
+		if iKnown != jKnown {
+			return iKnown
+		}
+		return iKnown && ri < rj
+	})
+	return sorted
+}
+
+// List books handler
+func booksHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = bookOrderCanonical
+	}
+	if order != bookOrderCanonical && order != bookOrderChronological {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid order '%s', expected canonical or chronological", order), http.StatusBadRequest)
+		return
+	}
+	includeApocrypha, err := parseIncludeApocrypha(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, "Invalid include_apocrypha value", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	books, err := fetchBooks(db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve books", http.StatusInternalServerError)
+		return
+	}
+	if !includeApocrypha {
+		canon := books[:0]
+		for _, book := range books {
+			if !apocryphaBooks[book.BookNumber] {
+				canon = append(canon, book)
+			}
+		}
+		books = canon
+	}
+	if order == bookOrderChronological {
+		books = sortBooksChronologically(books)
+	}
+
+	writeJSON(w, http.StatusOK, BooksResponse{
+		Translation: translationName,
+		Order:       order,
+		Books:       books,
+	})
+}
+
+// Per-translation book outline (chapter counts), cached since it never changes at runtime
+var outlineCache = make(map[string][]OutlineBook)
+var outlineMutex sync.Mutex
+
+// Get every book with its chapter count, cached per translation
+func getOutline(ctx context.Context, translationName string, db *sql.DB) ([]OutlineBook, error) {
+	outlineMutex.Lock()
+	defer outlineMutex.Unlock()
+
+	if outline, ok := outlineCache[translationName]; ok {
+		recordCacheResult(ctx, true)
+		return outline, nil
+	}
+	recordCacheResult(ctx, false)
+
+	rows, err := db.Query(`
+		SELECT b.book_number, b.short_name, b.long_name, c.chapters
+		FROM books b
+		JOIN (
+			SELECT book_number, COUNT(*) AS chapters
+			FROM (SELECT DISTINCT book_number, chapter FROM verses)
+			GROUP BY book_number
+		) c ON c.book_number = b.book_number
+		ORDER BY b.book_number
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outline []OutlineBook
+	for rows.Next() {
+		var book OutlineBook
+		if err := rows.Scan(&book.BookNumber, &book.ShortName, &book.LongName, &book.Chapters); err != nil {
+			return nil, err
+		}
+		outline = append(outline, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	outlineCache[translationName] = outline
+	return outline, nil
+}
+
+// Get the book name to book_number mapping of a translation handler; names
+// resolve the same way as book path segments, the first book winning a clash
+func bookNumbersHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	books, err := fetchBooks(db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve books", http.StatusInternalServerError)
+		return
+	}
+
+	numbers := make(map[string]int, len(books)*2)
+	for _, book := range books {
+		for _, name := range []string{book.ShortName, book.LongName} {
+			if _, taken := numbers[name]; !taken {
+				numbers[name] = book.BookNumber
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, BookNumbersResponse{
+		Translation: translationName,
+		Books:       numbers,
+	})
+}
+
+// Get the outline of a translation handler
+func outlineHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	includeVerses := false
+	if value := r.URL.Query().Get("verses"); value != "" {
+		var err error
+		includeVerses, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid verses value", http.StatusBadRequest)
+			return
+		}
+	}
+	includeApocrypha, err := parseIncludeApocrypha(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, "Invalid include_apocrypha value", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	cached, err := getOutline(r.Context(), translationName, db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve outline", http.StatusInternalServerError)
+		return
+	}
+
+	// Copy so per-request verse counts never touch the cached slice
+	outline := make([]OutlineBook, 0, len(cached))
+	for _, book := range cached {
+		if includeApocrypha || !apocryphaBooks[book.BookNumber] {
+			outline = append(outline, book)
+		}
+	}
+
+	if includeVerses {
+		counts, err := getBookVerseCounts(r.Context(), translationName, db)
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve outline", http.StatusInternalServerError)
+			return
+		}
+		verses := make(map[int]int, len(counts))
+		for _, c := range counts {
+			verses[c.BookNumber] = c.Verses
+		}
+		for i := range outline {
+			count := verses[outline[i].BookNumber]
+			outline[i].Verses = &count
+		}
+	}
+
+	writeJSON(w, http.StatusOK, OutlineResponse{
+		Translation: translationName,
+		Books:       outline,
+	})
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestParseBook(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	tests := []struct {
+		name     string
+		segment  string
+		want     int
+		notFound bool
+		invalid  bool
+	}{
+		{"book number", "10", 10, false, false},
+		{"unloaded book number", "730", 730, false, false},
+		{"short name", "Gen", 10, false, false},
+		{"long name", "Genesis", 10, false, false},
+		{"any case", "gEnEsIs", 10, false, false},
+		{"spaces ignored", "Gen esis", 10, false, false},
+		{"unknown name", "Judith", 0, true, false},
+		{"zero", "0", 0, false, true},
+		{"negative", "-10", 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBook("TEST", tt.segment)
+			var notFound *bookNotFoundError
+			switch {
+			case tt.notFound:
+				if !errors.As(err, &notFound) {
+					t.Fatalf("parseBook(%q) error = %v, want bookNotFoundError", tt.segment, err)
+				}
+			case tt.invalid:
+				if err == nil || errors.As(err, &notFound) {
+					t.Fatalf("parseBook(%q) error = %v, want an invalid book error", tt.segment, err)
+				}
+			case err != nil || got != tt.want:
+				t.Fatalf("parseBook(%q) = %d, %v, want %d", tt.segment, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookPathSegment(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	for _, target := range []string{"/get-chapter/TEST/10/1", "/get-chapter/TEST/Gen/1", "/get-chapter/TEST/genesis/1"} {
+		if rec := serve(http.MethodGet, target, ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200 (body %s)", target, rec.Code, rec.Body)
+		}
+	}
+	assertError(t, serve(http.MethodGet, "/get-chapter/TEST/Judith/1", ""), http.StatusNotFound, errCodeBookNotFound)
+	assertError(t, serve(http.MethodGet, "/get-chapter/TEST/0/1", ""), http.StatusBadRequest, errCodeInvalidReference)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type CaptionCard struct {
+	Lines []string `json:"lines"`
+}
+
+type CaptionResponse struct {
+	Translation string        `json:"translation"`
+	Reference   Reference     `json:"reference"`
+	MaxChars    int           `json:"max_chars"`
+	MaxLines    int           `json:"max_lines"`
+	Cards       []CaptionCard `json:"cards"`
+}
+
+// Caption size limits; 42 characters on 2 lines is the usual subtitle format
+const (
+	defaultCaptionChars = 42
+	minCaptionChars     = 10
+	maxCaptionChars     = 200
+	defaultCaptionLines = 2
+	maxCaptionLines     = 5
+)
+
+// Split text into caption cards of at most maxLines lines of at most maxChars
+// runes. Lines are filled word by word, and a word longer than a line is
+// split across lines. A card also closes after a line that ends a sentence,
+// so sentences start on a fresh card where possible.
+func captionCards(text string, maxChars, maxLines int) []CaptionCard {
+	cards := make([]CaptionCard, 0)
+	var lines []string
+	for _, line := range wrapText(text, maxChars) {
+		lines = append(lines, line)
+		if len(lines) == maxLines || endsSentence(line) {
+			cards = append(cards, CaptionCard{Lines: lines})
+			lines = nil
+		}
+	}
+	if len(lines) > 0 {
+		cards = append(cards, CaptionCard{Lines: lines})
+	}
+	return cards
+}
+
+// Whether text ends with terminal punctuation, ignoring closing quotes and brackets
+func endsSentence(text string) bool {
+	text = strings.TrimRight(text, sentenceClosers)
+	return strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?")
+}
+
+// Get a verse split into caption cards handler
+func captionHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	maxChars, err := parseIntParam(params.Get("max_chars"), defaultCaptionChars, minCaptionChars, maxCaptionChars)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid max_chars: %v", err), http.StatusBadRequest)
+		return
+	}
+	maxLines, err := parseIntParam(params.Get("max_lines"), defaultCaptionLines, 1, maxCaptionLines)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid max_lines: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := store.GetVerses(r.Context(), []Reference{ref})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	if verses[0] == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, CaptionResponse{
+		Translation: translationName,
+		Reference:   ref,
+		MaxChars:    maxChars,
+		MaxLines:    maxLines,
+		Cards:       captionCards(plainText(verses[0].Text), maxChars, maxLines),
+	})
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Bounded cache of encoded chapter responses, evicting the oldest entry when full
+type chapterCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string][]byte
+	order      []string
+	hits       uint64
+	misses     uint64
+	evictions  uint64
+}
+
+// Cache of default chapter responses (CHAPTER_CACHE_SIZE entries, 0 disables it)
+var chapterJSONCache = newChapterCache(2000)
+
+func newChapterCache(maxEntries int) *chapterCache {
+	return &chapterCache{maxEntries: maxEntries, entries: make(map[string][]byte)}
+}
+
+func chapterCacheKey(translationName string, book, chapter int) string {
+	return fmt.Sprintf("%s:%d:%d", translationName, book, chapter)
+}
+
+// Look up encoded chapter JSON, counting the hit or miss
+func (c *chapterCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	body, ok := c.entries[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return body, ok
+}
+
+// Store encoded chapter JSON
+func (c *chapterCache) put(key string, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries <= 0 {
+		return
+	}
+	if _, exists := c.entries[key]; exists {
+		return
+	}
+	for len(c.order) >= c.maxEntries {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+		c.evictions++
+	}
+	c.entries[key] = body
+	c.order = append(c.order, key)
+}
+
+// Drop every entry (on database reload); the counters keep running
+func (c *chapterCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string][]byte)
+	c.order = nil
+}
+
+// Snapshot of the cache counters for the metrics endpoint
+func (c *chapterCache) stats() (entries int, hits, misses, evictions uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries), c.hits, c.misses, c.evictions
+}
+
+// Encode a payload exactly as writeJSON does
+func encodeJSON(payload interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(payload); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write cached or freshly encoded JSON bytes
+func writeJSONBytes(w http.ResponseWriter, statusCode int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
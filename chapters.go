@@ -0,0 +1,527 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ChapterFootnotesResponse struct {
+	ChapterResponse
+	Footnotes []Footnote `json:"footnotes"`
+}
+
+type ChapterVerse struct {
+	Verse int    `json:"verse"`
+	Text  string `json:"text"`
+
+	// Set when the raw markup opens a new paragraph at this verse
+	paragraphStart bool
+	// Text as stored, kept for requests that choose their own transforms
+	rawText string
+}
+
+type ChapterResponse struct {
+	Translation    string           `json:"translation"`
+	BookNumber     int              `json:"book_number"`
+	BookTitle      string           `json:"book_title"`
+	BookTitleShort string           `json:"book_title_short"`
+	Chapter        int              `json:"chapter"`
+	Verses         []ChapterVerse   `json:"verses,omitempty"`
+	Paragraphs     [][]ChapterVerse `json:"paragraphs,omitempty"`
+	Text           string           `json:"text,omitempty"`
+}
+
+type CountResponse struct {
+	Translation string `json:"translation"`
+	BookNumber  int    `json:"book_number"`
+	Chapter     int    `json:"chapter,omitempty"`
+	Count       int    `json:"count"`
+}
+
+// Assemble a chapter with its cleaned verses; returns nil when the chapter does not exist
+func fetchChapter(ctx context.Context, db *sql.DB, translationName string, book, chapter int) (*ChapterResponse, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE v.book_number = ? AND v.chapter = ?
+		ORDER BY v.verse
+	`, book, chapter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	response := &ChapterResponse{
+		Translation: translationName,
+		BookNumber:  book,
+		Chapter:     chapter,
+		Verses:      make([]ChapterVerse, 0),
+	}
+	breakPending := false
+	for rows.Next() {
+		var verse ChapterVerse
+		var rawText string
+		if err := rows.Scan(&verse.Verse, &rawText, &response.BookTitleShort, &response.BookTitle); err != nil {
+			return nil, err
+		}
+		verse.Text = clearText(rawText)
+		verse.rawText = rawText
+
+		// A break at the start opens a paragraph here; one elsewhere opens it at the next verse
+		trimmed := strings.TrimSpace(rawText)
+		verse.paragraphStart = breakPending || strings.HasPrefix(trimmed, paragraphMarker)
+		breakPending = strings.Contains(strings.TrimPrefix(trimmed, paragraphMarker), paragraphMarker)
+		response.Verses = append(response.Verses, verse)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(response.Verses) == 0 {
+		return nil, nil
+	}
+	return response, nil
+}
+
+// Parse ?order (asc or desc, default asc); reports whether verses go last to first
+func parseOrder(r *http.Request) (bool, error) {
+	switch value := r.URL.Query().Get("order"); value {
+	case "", "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, fmt.Errorf("Invalid order '%s', expected asc or desc", value)
+	}
+}
+
+// Reverse chapter verses in place; fetchChapter reads them in ascending order
+// because paragraph starts depend on the verse before
+func reverseChapterVerses(verses []ChapterVerse) {
+	for i, j := 0, len(verses)-1; i < j; i, j = i+1, j-1 {
+		verses[i], verses[j] = verses[j], verses[i]
+	}
+}
+
+// MyBible markup for a paragraph break
+const paragraphMarker = "<pb/>"
+
+// Group a chapter's verses by the paragraph breaks in their markup; without
+// any markup every verse is its own paragraph
+func groupParagraphs(verses []ChapterVerse) [][]ChapterVerse {
+	marked := false
+	for _, verse := range verses {
+		if verse.paragraphStart {
+			marked = true
+			break
+		}
+	}
+
+	paragraphs := make([][]ChapterVerse, 0)
+	for i, verse := range verses {
+		if i == 0 || !marked || verse.paragraphStart {
+			paragraphs = append(paragraphs, nil)
+		}
+		last := len(paragraphs) - 1
+		paragraphs[last] = append(paragraphs[last], verse)
+	}
+	return paragraphs
+}
+
+// Join a chapter's verses into reading text with inline "[n]" verse markers
+func inlineChapterText(verses []ChapterVerse) string {
+	parts := make([]string, len(verses))
+	for i, verse := range verses {
+		parts[i] = "[" + strconv.Itoa(verse.Verse) + "] " + verse.Text
+	}
+	return strings.Join(parts, " ")
+}
+
+// Maximum number of chapters returned by one multi-chapter request
+const maxChapterSpan = 10
+
+// Get a range of chapters of one book handler
+func getChaptersHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	from, err := parseIntParam(params.Get("from"), 1, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+		return
+	}
+	to, err := parseIntParam(params.Get("to"), from, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+		return
+	}
+	if from > to {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'from' must not be greater than 'to'", http.StatusBadRequest)
+		return
+	}
+	if to-from+1 > maxChapterSpan {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("At most %d chapters can be requested at once", maxChapterSpan), http.StatusBadRequest)
+		return
+	}
+	descending, err := parseOrder(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	chapters := make([]*ChapterResponse, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		chapter, err := store.Chapter(r.Context(), ref.Book, number)
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve chapters", http.StatusInternalServerError)
+			return
+		}
+		if chapter == nil {
+			respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Chapter %d:%d not found", ref.Book, number), http.StatusNotFound)
+			return
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	if descending {
+		for i, j := 0, len(chapters)-1; i < j; i, j = i+1, j-1 {
+			chapters[i], chapters[j] = chapters[j], chapters[i]
+		}
+		for _, chapter := range chapters {
+			reverseChapterVerses(chapter.Verses)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, chapters)
+}
+
+// Line width limits for the plain text chapter view
+const (
+	defaultPlainWidth = 40
+	minPlainWidth     = 10
+	maxPlainWidth     = 200
+)
+
+// Get a chapter as pre-wrapped plain text handler
+func plainChapterHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	width, err := parseIntParam(r.URL.Query().Get("width"), defaultPlainWidth, minPlainWidth, maxPlainWidth)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid width: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	chapter, err := store.Chapter(r.Context(), ref.Book, ref.Chapter)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter", http.StatusInternalServerError)
+		return
+	}
+	if chapter == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Chapter %d:%d not found", ref.Book, ref.Chapter), http.StatusNotFound)
+		return
+	}
+
+	// Keep each verse marker on the same line as the verse's first word
+	var words []string
+	for _, verse := range chapter.Verses {
+		fields := strings.Fields(plainText(verse.Text))
+		marker := "[" + strconv.Itoa(verse.Verse) + "]"
+		if len(fields) == 0 {
+			words = append(words, marker)
+			continue
+		}
+		fields[0] = marker + " " + fields[0]
+		words = append(words, fields...)
+	}
+
+	heading := fmt.Sprintf("%s %d", chapter.BookTitle, chapter.Chapter)
+	lines := append(wrapText(heading, width), "")
+	lines = append(lines, wrapWords(words, width)...)
+	respondWithPlainText(w, strings.Join(lines, "\n")+"\n")
+}
+
+// Get a whole chapter handler
+func getChapterHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	inlineNumbers := false
+	if value := r.URL.Query().Get("inline_numbers"); value != "" {
+		inlineNumbers, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid inline_numbers value", http.StatusBadRequest)
+			return
+		}
+	}
+	paragraphs := false
+	if value := r.URL.Query().Get("paragraphs"); value != "" {
+		paragraphs, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid paragraphs value", http.StatusBadRequest)
+			return
+		}
+	}
+	if inlineNumbers && paragraphs {
+		respondWithError(w, errCodeInvalidParameter, "Parameters 'inline_numbers' and 'paragraphs' cannot be combined", http.StatusBadRequest)
+		return
+	}
+	transforms, err := parseTransforms(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strongs, err := parseStrongs(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// HTML is rendered from the stored markup, so the text options do not apply
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "html" && format != "csv" {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unsupported format '%s'", format), http.StatusBadRequest)
+		return
+	}
+	if format == "html" && (inlineNumbers || paragraphs || transforms != nil || strongs != strongsStrip) {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'format=html' cannot be combined with inline_numbers, paragraphs, transforms or strongs", http.StatusBadRequest)
+		return
+	}
+	descending, err := parseOrder(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	footnotes, err := parseFootnotes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if footnotes && format == "html" {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'footnotes' cannot be combined with format=html", http.StatusBadRequest)
+		return
+	}
+	// CSV has one row per verse and no room for footnotes
+	if format == "csv" && (inlineNumbers || paragraphs || footnotes) {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'format=csv' cannot be combined with inline_numbers, paragraphs or footnotes", http.StatusBadRequest)
+		return
+	}
+	// Paragraphs are grouped in reading order
+	if descending && (format == "html" || inlineNumbers || paragraphs) {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'order=desc' cannot be combined with format=html, inline_numbers or paragraphs", http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	// Only the default representation is cached; options change the body
+	cacheable := format != "html" && format != "csv" && !inlineNumbers && !paragraphs && !descending && !footnotes && transforms == nil && strongs == strongsStrip
+	cacheKey := chapterCacheKey(translationName, ref.Book, ref.Chapter)
+	if cacheable {
+		if body, ok := chapterJSONCache.get(cacheKey); ok {
+			recordCacheResult(r.Context(), true)
+			writeJSONBytes(w, http.StatusOK, body)
+			return
+		}
+		recordCacheResult(r.Context(), false)
+	}
+
+	chapter, err := store.Chapter(r.Context(), ref.Book, ref.Chapter)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter", http.StatusInternalServerError)
+		return
+	}
+	if chapter == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Chapter %d:%d not found", ref.Book, ref.Chapter), http.StatusNotFound)
+		return
+	}
+
+	if format == "html" {
+		dbMutex.RLock()
+		language := translationLanguages[translationName]
+		direction := translationMetadata[translationName].Direction
+		dbMutex.RUnlock()
+		respondWithHTML(w, chapterHTML(chapter, language, direction))
+		return
+	}
+
+	// Footnote markers are lettered across the whole chapter
+	notes := make([]Footnote, 0)
+	if footnotes {
+		for i := range chapter.Verses {
+			raw, found := extractFootnotes(chapter.Verses[i].rawText, chapter.Verses[i].Verse, len(notes))
+			chapter.Verses[i].Text = renderText(raw, ref.Book, transforms, strongs)
+			notes = append(notes, found...)
+		}
+	} else if transforms != nil || strongs != strongsStrip {
+		for i := range chapter.Verses {
+			chapter.Verses[i].Text = renderText(chapter.Verses[i].rawText, ref.Book, transforms, strongs)
+		}
+	}
+
+	if descending {
+		reverseChapterVerses(chapter.Verses)
+	}
+
+	if format == "csv" {
+		rows := make([][]string, len(chapter.Verses))
+		for i, verse := range chapter.Verses {
+			rows[i] = csvRow(ref.Book, ref.Chapter, verse.Verse, verse.Text)
+		}
+		respondWithCSV(w, fmt.Sprintf("%s-%d-%d.csv", translationName, ref.Book, ref.Chapter), rows)
+		return
+	}
+
+	// Paragraph view: one reading string instead of the verse array
+	if inlineNumbers {
+		chapter.Text = inlineChapterText(chapter.Verses)
+		chapter.Verses = nil
+	}
+	if paragraphs {
+		chapter.Paragraphs = groupParagraphs(chapter.Verses)
+		chapter.Verses = nil
+	}
+
+	if cacheable {
+		body, err := encodeJSON(chapter)
+		if err != nil {
+			log.Printf("Failed to encode chapter for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve chapter", http.StatusInternalServerError)
+			return
+		}
+		chapterJSONCache.put(cacheKey, body)
+		writeJSONBytes(w, http.StatusOK, body)
+		return
+	}
+
+	if footnotes {
+		writeJSON(w, http.StatusOK, ChapterFootnotesResponse{ChapterResponse: *chapter, Footnotes: notes})
+		return
+	}
+	writeJSON(w, http.StatusOK, chapter)
+}
+
+// Count verses in a book or chapter handler. With a chapter, from/to bound
+// the verse numbers; without one they bound the chapter numbers.
+func countHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	from, err := parseIntParam(params.Get("from"), 1, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid from: %v", err), http.StatusBadRequest)
+		return
+	}
+	to, err := parseIntParam(params.Get("to"), math.MaxInt32, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid to: %v", err), http.StatusBadRequest)
+		return
+	}
+	if from > to {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'from' must not be greater than 'to'", http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	response := CountResponse{Translation: translationName, BookNumber: ref.Book, Chapter: ref.Chapter}
+	if ref.Chapter > 0 {
+		err = db.QueryRowContext(r.Context(), `
+			SELECT COUNT(*) FROM verses
+			WHERE book_number = ? AND chapter = ? AND verse BETWEEN ? AND ?
+		`, ref.Book, ref.Chapter, from, to).Scan(&response.Count)
+	} else {
+		err = db.QueryRowContext(r.Context(), `
+			SELECT COUNT(*) FROM verses
+			WHERE book_number = ? AND chapter BETWEEN ? AND ?
+		`, ref.Book, from, to).Scan(&response.Count)
+	}
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to count verses", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Get a random chapter handler; every chapter is equally likely regardless of its length
+func getRandomChapterHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	var book, number int
+	err := db.QueryRowContext(r.Context(), `
+		SELECT book_number, chapter
+		FROM (SELECT DISTINCT book_number, chapter FROM verses)
+		ORDER BY RANDOM()
+		LIMIT 1
+	`).Scan(&book, &number)
+	if err == sql.ErrNoRows {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Translation '%s' has no chapters", translationName), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter", http.StatusInternalServerError)
+		return
+	}
+
+	chapter, err := fetchChapter(r.Context(), db, translationName, book, number)
+	if err != nil || chapter == nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, chapter)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type ClientRequestCount struct {
+	IP       string `json:"ip"`
+	Requests int64  `json:"requests"`
+}
+
+type AdminStatsResponse struct {
+	Since             string               `json:"since"`
+	TrackedClients    int                  `json:"tracked_clients"`
+	UntrackedRequests int64                `json:"untracked_requests"`
+	MaxTrackedClients int                  `json:"max_tracked_clients"`
+	Clients           []ClientRequestCount `json:"clients"`
+}
+
+// Upper bound on client IPs whose requests are counted individually
+const maxCountedClients = 10000
+
+// Clients listed by /admin/stats unless ?limit says otherwise
+const (
+	defaultTopClients = 20
+	maxTopClients     = 1000
+)
+
+// Requests from one client IP
+type clientCount struct {
+	ip       string
+	requests int64
+	element  *list.Element
+}
+
+// Requests per client IP since startup
+type clientCounter struct {
+	mu     sync.Mutex
+	counts map[string]*clientCount
+	// Clients from most to least recently seen
+	order *list.List
+	// Requests from clients dropped to make room for newer ones
+	untracked int64
+}
+
+var clientRequests = &clientCounter{counts: make(map[string]*clientCount), order: list.New()}
+
+// Count one request from ip
+func (c *clientCounter) add(ip string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	client, ok := c.counts[ip]
+	if ok {
+		c.order.MoveToFront(client.element)
+	} else {
+		if len(c.counts) >= maxCountedClients {
+			c.evict()
+		}
+		client = &clientCount{ip: ip}
+		client.element = c.order.PushFront(client)
+		c.counts[ip] = client
+	}
+	client.requests++
+}
+
+// Drop the least recently seen client, keeping its requests in the untracked total
+func (c *clientCounter) evict() {
+	element := c.order.Back()
+	if element == nil {
+		return
+	}
+	client := c.order.Remove(element).(*clientCount)
+	delete(c.counts, client.ip)
+	c.untracked += client.requests
+}
+
+// The n clients with the most requests, busiest first, plus the totals
+func (c *clientCounter) top(n int) ([]ClientRequestCount, int, int64) {
+	c.mu.Lock()
+	clients := make([]ClientRequestCount, 0, len(c.counts))
+	for ip, client := range c.counts {
+		clients = append(clients, ClientRequestCount{IP: ip, Requests: client.requests})
+	}
+	untracked := c.untracked
+	c.mu.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Requests != clients[j].Requests {
+			return clients[i].Requests > clients[j].Requests
+		}
+		return clients[i].IP < clients[j].IP
+	})
+	tracked := len(clients)
+	if len(clients) > n {
+		clients = clients[:n]
+	}
+	return clients, tracked, untracked
+}
+
+// Request counting middleware
+func clientCountMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientRequests.add(clientIP(r))
+		next(w, r)
+	}
+}
+
+// Get the busiest client IPs since startup handler
+func adminStatsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseIntParam(r.URL.Query().Get("limit"), defaultTopClients, 1, maxTopClients)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid limit: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	clients, tracked, untracked := clientRequests.top(limit)
+	writeJSON(w, http.StatusOK, AdminStatsResponse{
+		Since:             startTime.UTC().Format(time.RFC3339),
+		TrackedClients:    tracked,
+		UntrackedRequests: untracked,
+		MaxTrackedClients: maxCountedClients,
+		Clients:           clients,
+	})
+}
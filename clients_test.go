@@ -0,0 +1,37 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"testing"
+)
+
+func TestClientCounterEvictsLeastRecentlySeen(t *testing.T) {
+	c := &clientCounter{counts: make(map[string]*clientCount), order: list.New()}
+	for i := 0; i < maxCountedClients; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+		c.add(ip)
+		c.add(ip)
+	}
+
+	// A returning client moves to the front, so the next oldest makes room
+	c.add("10.0.0.0")
+	c.add("192.0.2.1")
+	if len(c.counts) != maxCountedClients || c.order.Len() != maxCountedClients {
+		t.Fatalf("tracking %d clients (%d ordered), want %d", len(c.counts), c.order.Len(), maxCountedClients)
+	}
+	if _, ok := c.counts["10.0.0.1"]; ok {
+		t.Error("least recently seen client was kept")
+	}
+	if client, ok := c.counts["10.0.0.0"]; !ok || client.requests != 3 {
+		t.Errorf("returning client = %+v, want 3 requests", client)
+	}
+
+	clients, tracked, untracked := c.top(1)
+	if tracked != maxCountedClients || untracked != 2 {
+		t.Errorf("tracked = %d, untracked = %d, want %d and 2", tracked, untracked, maxCountedClients)
+	}
+	if len(clients) != 1 || clients[0].IP != "10.0.0.0" {
+		t.Errorf("top client = %+v, want 10.0.0.0", clients)
+	}
+}
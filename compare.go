@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type TranslationWarning struct {
+	Translation string `json:"translation"`
+	Error       string `json:"error"`
+}
+
+type CompareResponse struct {
+	Reference Reference              `json:"reference"`
+	Verses    map[string]interface{} `json:"verses"`
+	Warnings  []TranslationWarning   `json:"warnings"`
+}
+
+type AvailabilityResponse struct {
+	Reference Reference              `json:"reference"`
+	Available map[string]interface{} `json:"available"`
+	Warnings  []TranslationWarning   `json:"warnings"`
+}
+
+type VerseLength struct {
+	Text       string `json:"text"`
+	Characters int    `json:"characters"`
+	Words      int    `json:"words"`
+}
+
+type LengthCompareResponse struct {
+	Reference Reference              `json:"reference"`
+	Lengths   map[string]interface{} `json:"lengths"`
+	Warnings  []TranslationWarning   `json:"warnings"`
+}
+
+type ParallelResponse struct {
+	BookNumber int                    `json:"book_number"`
+	Chapter    int                    `json:"chapter"`
+	Chapters   map[string]interface{} `json:"chapters"`
+	Warnings   []TranslationWarning   `json:"warnings"`
+}
+
+// Outcome of a lookup in one translation
+type translationResult struct {
+	value interface{}
+	err   error
+}
+
+// Parse ?translations=A,B (default: every loaded translation, sorted).
+// Unknown names are a client error; configured but unloaded ones are left
+// for the lookup to report as warnings.
+func parseTranslationList(r *http.Request) ([]string, error) {
+	value := r.URL.Query().Get("translations")
+	if value == "" {
+		dbMutex.RLock()
+		names := make([]string, 0, len(dbPool))
+		for name := range dbPool {
+			names = append(names, name)
+		}
+		dbMutex.RUnlock()
+		sort.Strings(names)
+		return names, nil
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, exists := translations[name]; !exists {
+			return nil, fmt.Errorf("Translation '%s' not found", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Run fn concurrently for each translation. Successful values are keyed by
+// translation name; failures (including unavailable databases) are logged and
+// returned as warnings so one broken translation does not fail the request.
+func collectTranslations(names []string, fn func(name string, db *sql.DB) (interface{}, error)) (map[string]interface{}, []TranslationWarning) {
+	results := make([]translationResult, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		dbMutex.RLock()
+		db, exists := dbPool[name]
+		dbMutex.RUnlock()
+
+		if !exists {
+			results[i].err = fmt.Errorf("Database for translation '%s' is not available", name)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, name string, db *sql.DB) {
+			defer wg.Done()
+			value, err := fn(name, db)
+			if err != nil {
+				logQueryError(name, err)
+				err = fmt.Errorf("Failed to retrieve data from translation '%s'", name)
+			}
+			results[i] = translationResult{value: value, err: err}
+		}(i, name, db)
+	}
+	wg.Wait()
+
+	values := make(map[string]interface{}, len(names))
+	warnings := make([]TranslationWarning, 0)
+	for i, name := range names {
+		if results[i].err != nil {
+			warnings = append(warnings, TranslationWarning{Translation: name, Error: results[i].err.Error()})
+			continue
+		}
+		values[name] = results[i].value
+	}
+	return values, warnings
+}
+
+// Respond with collected results, failing only when every translation failed
+// (503) or none of them has the requested text (404). Lookups report a miss
+// as an untyped nil value.
+func respondWithCollected(w http.ResponseWriter, values map[string]interface{}, warnings []TranslationWarning, build func() interface{}) {
+	if len(values) == 0 && len(warnings) > 0 {
+		respondWithError(w, errCodeDatabaseUnavailable, "No translation could be queried", http.StatusServiceUnavailable)
+		return
+	}
+	found := false
+	for _, value := range values {
+		if value != nil {
+			found = true
+			break
+		}
+	}
+	if len(values) > 0 && !found {
+		respondWithError(w, errCodeVerseNotFound, "Reference not found in any translation", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, build())
+}
+
+// Look up one verse for collectTranslations, reporting a miss as nil
+func collectVerse(ctx context.Context, ref Reference, canonical bool) func(name string, db *sql.DB) (interface{}, error) {
+	return func(name string, db *sql.DB) (interface{}, error) {
+		stored := ref
+		if canonical {
+			stored = canonicalReference(name, ref)
+		}
+		verses, err := fetchVerses(ctx, db, name, []Reference{stored})
+		if err != nil || verses[0] == nil {
+			return nil, err
+		}
+		return verses[0], nil
+	}
+}
+
+// Compare one verse across translations handler
+func compareHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	names, err := parseTranslationList(r)
+	if err != nil {
+		respondWithError(w, errCodeTranslationNotFound, err.Error(), http.StatusNotFound)
+		return
+	}
+	canonical, err := parseCanonical(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	values, warnings := collectTranslations(names, collectVerse(r.Context(), ref, canonical))
+
+	respondWithCollected(w, values, warnings, func() interface{} {
+		return CompareResponse{Reference: ref, Verses: values, Warnings: warnings}
+	})
+}
+
+// Report which translations contain a verse handler; unlike compare, a
+// verse missing everywhere is still a 200 with every value false
+func availabilityHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	names, err := parseTranslationList(r)
+	if err != nil {
+		respondWithError(w, errCodeTranslationNotFound, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	values, warnings := collectTranslations(names, func(name string, db *sql.DB) (interface{}, error) {
+		var exists bool
+		err := db.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM verses WHERE book_number = ? AND chapter = ? AND verse = ?)",
+			ref.Book, ref.Chapter, ref.Verse,
+		).Scan(&exists)
+		return exists, err
+	})
+	if len(values) == 0 && len(warnings) > 0 {
+		respondWithError(w, errCodeDatabaseUnavailable, "No translation could be queried", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AvailabilityResponse{Reference: ref, Available: values, Warnings: warnings})
+}
+
+// Compare the length of one verse across translations handler, for sizing
+// parallel columns before rendering
+func lengthCompareHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	names, err := parseTranslationList(r)
+	if err != nil {
+		respondWithError(w, errCodeTranslationNotFound, err.Error(), http.StatusNotFound)
+		return
+	}
+	canonical, err := parseCanonical(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lookup := collectVerse(r.Context(), ref, canonical)
+	values, warnings := collectTranslations(names, func(name string, db *sql.DB) (interface{}, error) {
+		value, err := lookup(name, db)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		text := value.(*VerseResponse).Text
+		return VerseLength{
+			Text:       text,
+			Characters: utf8.RuneCountInString(text),
+			Words:      len(strings.Fields(text)),
+		}, nil
+	})
+
+	respondWithCollected(w, values, warnings, func() interface{} {
+		return LengthCompareResponse{Reference: ref, Lengths: values, Warnings: warnings}
+	})
+}
+
+// Parallel chapter view across translations handler
+func parallelHandler(w http.ResponseWriter, r *http.Request) {
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	names, err := parseTranslationList(r)
+	if err != nil {
+		respondWithError(w, errCodeTranslationNotFound, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	values, warnings := collectTranslations(names, func(name string, db *sql.DB) (interface{}, error) {
+		chapter, err := fetchChapter(r.Context(), db, name, ref.Book, ref.Chapter)
+		if err != nil || chapter == nil {
+			return nil, err
+		}
+		return chapter, nil
+	})
+
+	respondWithCollected(w, values, warnings, func() interface{} {
+		return ParallelResponse{BookNumber: ref.Book, Chapter: ref.Chapter, Chapters: values, Warnings: warnings}
+	})
+}
+
+// Random verse shown in several translations handler
+func randomCompareHandler(w http.ResponseWriter, r *http.Request) {
+	names, err := parseTranslationList(r)
+	if err != nil {
+		respondWithError(w, errCodeTranslationNotFound, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Pick the reference from the first translation that can serve one
+	var ref Reference
+	var picked, failed bool
+	for _, name := range names {
+		dbMutex.RLock()
+		db, exists := dbPool[name]
+		dbMutex.RUnlock()
+		if !exists {
+			failed = true
+			continue
+		}
+		err := db.QueryRow("SELECT book_number, chapter, verse FROM verses ORDER BY RANDOM() LIMIT 1").Scan(&ref.Book, &ref.Chapter, &ref.Verse)
+		if err == nil {
+			picked = true
+			break
+		}
+		if err != sql.ErrNoRows {
+			logQueryError(name, err)
+			failed = true
+		}
+	}
+	if !picked && failed {
+		respondWithError(w, errCodeDatabaseUnavailable, "No translation could be queried", http.StatusServiceUnavailable)
+		return
+	}
+	if !picked {
+		respondWithError(w, errCodeVerseNotFound, "No verse to choose from", http.StatusNotFound)
+		return
+	}
+
+	values, warnings := collectTranslations(names, collectVerse(r.Context(), ref, false))
+
+	respondWithCollected(w, values, warnings, func() interface{} {
+		return CompareResponse{Reference: ref, Verses: values, Warnings: warnings}
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Compression level used for gzip responses (GZIP_LEVEL)
+var gzipLevel = gzip.BestSpeed
+
+// Reuse gzip writers across responses; they are expensive to allocate
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzipLevel)
+		return w
+	},
+}
+
+// Offer Brotli ahead of gzip to clients that accept it (BROTLI)
+var brotliEnabled = false
+
+// Brotli quality for responses; 4 compresses better than gzip at similar speed
+const brotliLevel = 4
+
+var brotliWriterPool = sync.Pool{
+	New: func() interface{} {
+		return brotli.NewWriterLevel(nil, brotliLevel)
+	},
+}
+
+// A pooled streaming compressor
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Content codings the middleware can produce, with their writer pools
+var compressorPools = map[string]*sync.Pool{
+	"gzip": &gzipWriterPool,
+	"br":   &brotliWriterPool,
+}
+
+// Media types that are already compressed; recompressing them only costs CPU
+var precompressedTypes = map[string]bool{
+	"image/png":        true,
+	"image/jpeg":       true,
+	"image/gif":        true,
+	"image/webp":       true,
+	"application/zip":  true,
+	"application/gzip": true,
+}
+
+// Response writer compressing the body. The status is held back until the
+// first body byte, so bodiless responses (such as CORS preflights) and
+// precompressed content go out without Content-Encoding.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	cw          compressor
+	status      int
+	wroteHeader bool
+}
+
+func (c *compressResponseWriter) WriteHeader(statusCode int) {
+	if !c.wroteHeader && c.status == 0 {
+		c.status = statusCode
+	}
+}
+
+// Send the held status, switching to the compressor when compress is set
+func (c *compressResponseWriter) writeHeader(compress bool) {
+	c.wroteHeader = true
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if compress {
+		c.Header().Del("Content-Length")
+		c.Header().Set("Content-Encoding", c.encoding)
+		c.cw = compressorPools[c.encoding].Get().(compressor)
+		c.cw.Reset(c.ResponseWriter)
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+}
+
+// Check whether a body starting with b is worth compressing
+func (c *compressResponseWriter) compressible(b []byte) bool {
+	if c.status == http.StatusNoContent || c.status == http.StatusNotModified || c.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	// Sniff the type now; after compression the server would sniff compressed bytes
+	if c.Header().Get("Content-Type") == "" {
+		c.Header().Set("Content-Type", http.DetectContentType(b))
+	}
+	mediaType, _, _ := strings.Cut(c.Header().Get("Content-Type"), ";")
+	return !precompressedTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+}
+
+func (c *compressResponseWriter) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		if len(b) == 0 {
+			return 0, nil
+		}
+		c.writeHeader(c.compressible(b))
+	}
+	if c.cw == nil {
+		return c.ResponseWriter.Write(b)
+	}
+	return c.cw.Write(b)
+}
+
+// Flush compressed data so far, then the underlying writer, for streamed
+// responses; nothing is sent while the status is still held back
+func (c *compressResponseWriter) Flush() {
+	if !c.wroteHeader {
+		return
+	}
+	if c.cw != nil {
+		c.cw.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Send a status that never got a body, then release the compressor
+func (c *compressResponseWriter) close() {
+	if !c.wroteHeader && c.status != 0 {
+		c.writeHeader(false)
+	}
+	if c.cw != nil {
+		c.cw.Close()
+		compressorPools[c.encoding].Put(c.cw)
+	}
+}
+
+// Check whether the client accepts a content coding
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), encoding) {
+			return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// Pick the response coding: br when enabled and accepted, then gzip, else
+// none (identity)
+func negotiateEncoding(r *http.Request) string {
+	if brotliEnabled && acceptsEncoding(r, "br") {
+		return "br"
+	}
+	if acceptsEncoding(r, "gzip") {
+		return "gzip"
+	}
+	return ""
+}
+
+// Request headers the representation may depend on; sent as Vary on every
+// response so shared caches keep compressed and negotiated variants apart
+var varyHeaders = []string{"Accept", "Accept-Encoding"}
+
+// Add fields to the Vary header, skipping ones it already lists
+func addVary(h http.Header, fields ...string) {
+	listed := make(map[string]bool)
+	for _, value := range h.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			listed[strings.ToLower(strings.TrimSpace(field))] = true
+		}
+	}
+	var missing []string
+	for _, field := range fields {
+		if !listed[strings.ToLower(field)] {
+			listed[strings.ToLower(field)] = true
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		h.Add("Vary", strings.Join(missing, ", "))
+	}
+}
+
+// Response compression middleware (Brotli or gzip)
+func compressionMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), varyHeaders...)
+		encoding := negotiateEncoding(r)
+		if r.Method == http.MethodHead || encoding == "" {
+			next(w, r)
+			return
+		}
+
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next(cw, r)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Send a request through the full router with gzip accepted
+func serveGzip(method, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompressionOfJSON(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	rec := serveGzip(http.MethodGet, "/get-verse/TEST/10/1/1")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("status %d, Content-Encoding %q, want a gzip 200", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verse VerseResponse
+	if err := json.NewDecoder(zr).Decode(&verse); err != nil || verse.Verse != 1 {
+		t.Fatalf("decoded %+v, %v", verse, err)
+	}
+
+	// Errors have a body too and are compressed like any other response
+	if rec := serveGzip(http.MethodGet, "/get-verse/TEST/10/1/99"); rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Errorf("404 Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressionSkipsBodilessResponses(t *testing.T) {
+	rec := serveGzip(http.MethodOptions, "/get-verse/KJV/10/1/1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preflight status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("preflight Content-Encoding = %q, want none", got)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("preflight body = %q, want empty", rec.Body)
+	}
+}
+
+func TestCompressionSkipsPNG(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	rec := serveGzip(http.MethodGet, "/qr/TEST/10/1/1")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("status %d, Content-Type %q, want a PNG", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("PNG Content-Encoding = %q, want none", got)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if len(body) < 8 || string(body[1:4]) != "PNG" {
+		t.Errorf("body does not start with the PNG signature")
+	}
+}
+
+func TestCompressResponseWriterHeldStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	cw := &compressResponseWriter{ResponseWriter: rec, encoding: "gzip"}
+	cw.WriteHeader(http.StatusNoContent)
+	cw.Write(nil)
+	cw.close()
+
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("status %d, Content-Encoding %q, want a plain 204", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type ConcordanceResponse struct {
+	Translation string        `json:"translation"`
+	Word        string        `json:"word"`
+	N           int           `json:"n"`
+	Occurrence  int           `json:"occurrence_in_verse"`
+	Verse       VerseResponse `json:"verse"`
+}
+
+// Limits of a concordance lookup
+const (
+	maxConcordanceWordLen = 50
+	maxConcordanceIndex   = 10000
+)
+
+// Count whole-word occurrences of word in normalized text; RE2's \b only
+// knows ASCII, so boundaries are checked rune by rune
+func countWordOccurrences(text, word string) int {
+	count := 0
+	for start := 0; ; {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return count
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			count++
+		}
+		start = end
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// Get the verse holding the n-th occurrence of a word in canonical order handler
+func concordanceHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	params := r.URL.Query()
+
+	word := strings.TrimSpace(params.Get("word"))
+	if word == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'word' is required", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(word) > maxConcordanceWordLen {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Word exceeds %d characters", maxConcordanceWordLen), http.StatusBadRequest)
+		return
+	}
+	for _, r := range word {
+		if !isWordRune(r) {
+			respondWithError(w, errCodeInvalidParameter, "Parameter 'word' must be a single word of letters or digits", http.StatusBadRequest)
+			return
+		}
+	}
+
+	n, err := parseIntParam(params.Get("n"), 1, 1, maxConcordanceIndex)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid n: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	dbMutex.RLock()
+	language := translationLanguages[translationName]
+	dbMutex.RUnlock()
+
+	normalized := normalizeSearchText(word, language, true)
+
+	// The LIKE prefilter narrows the scan to verses containing the word at all
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE normalize_text(v.text, ?, 1) LIKE ? ESCAPE '\'
+		ORDER BY v.book_number, v.chapter, v.verse
+	`, language, "%"+escapeLike(normalized)+"%")
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to search verses", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	seen := 0
+	for rows.Next() {
+		var verse VerseResponse
+		var rawText string
+		if err := rows.Scan(&verse.BookNumber, &verse.Chapter, &verse.Verse, &rawText, &verse.BookTitleShort, &verse.BookTitle); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to search verses", http.StatusInternalServerError)
+			return
+		}
+		verse.Text = clearText(rawText)
+
+		count := countWordOccurrences(normalizeSearchText(verse.Text, language, true), normalized)
+		if seen+count < n {
+			seen += count
+			continue
+		}
+		verse.Translation = translationName
+		writeJSON(w, http.StatusOK, ConcordanceResponse{
+			Translation: translationName,
+			Word:        word,
+			N:           n,
+			Occurrence:  n - seen,
+			Verse:       verse,
+		})
+		return
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to search verses", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithError(w, errCodeNotFound, fmt.Sprintf("Word '%s' occurs only %d times", word, seen), http.StatusNotFound)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Slots for in-flight requests (nil when MAX_CONCURRENT_REQUESTS is unset or 0)
+var requestSlots chan struct{}
+
+// How long a request may wait for a free slot before being shed (MAX_CONCURRENT_WAIT)
+var concurrencyWait time.Duration
+
+// Seconds suggested to shed clients via Retry-After (CONCURRENCY_RETRY_AFTER)
+var concurrencyRetryAfter = 1
+
+// Cap concurrent in-flight requests, answering 503 instead of queueing indefinitely
+func concurrencyLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case requestSlots <- struct{}{}:
+		default:
+			if !waitForSlot(r) {
+				w.Header().Set(headerRetryAfter, strconv.Itoa(concurrencyRetryAfter))
+				respondWithError(w, errCodeOverloaded, "Server is busy, try again later", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		defer func() { <-requestSlots }()
+		next(w, r)
+	}
+}
+
+// Wait up to concurrencyWait for a slot; false when none freed up in time
+func waitForSlot(r *http.Request) bool {
+	if concurrencyWait <= 0 {
+		return false
+	}
+	timer := time.NewTimer(concurrencyWait)
+	defer timer.Stop()
+	select {
+	case requestSlots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
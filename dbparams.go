@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Connection parameters a translation may add to its DSN (DB_PARAMS_<NAME>),
+// with their validators. page_size is not among them: it is fixed when the
+// file is created and cannot change on a read-only database.
+var connParamValidators = map[string]func(string) error{
+	"_busy_timeout": nonNegativeIntParam,
+	"_cache_size":   intParam,
+	"_mmap_size":    nonNegativeIntParam,
+	"immutable": func(value string) error {
+		if value != "0" && value != "1" {
+			return fmt.Errorf("expected 0 or 1")
+		}
+		return nil
+	},
+}
+
+func intParam(value string) error {
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err
+}
+
+func nonNegativeIntParam(value string) error {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && n < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return err
+}
+
+// Extra connection parameters per translation; translations without an
+// entry use the defaults (read-only, shared cache)
+var translationConnParams = make(map[string]url.Values)
+
+// Parse DB_PARAMS_<NAME> ("key=value&key=value"), rejecting unknown keys and
+// invalid values
+func parseConnParams(value string) (url.Values, error) {
+	params, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range params {
+		validate, known := connParamValidators[key]
+		if !known {
+			supported := make([]string, 0, len(connParamValidators))
+			for name := range connParamValidators {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+			return nil, fmt.Errorf("unsupported parameter %q (expected one of %s)", key, strings.Join(supported, ", "))
+		}
+		if len(values) != 1 {
+			return nil, fmt.Errorf("parameter %q given more than once", key)
+		}
+		if err := validate(values[0]); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", key, values[0], err)
+		}
+	}
+	return params, nil
+}
+
+// mmap_size per absolute database path; the driver has no DSN option for it,
+// so the connect hook applies it as a pragma on every new connection
+var mmapSizes = make(map[string]string)
+var mmapMutex sync.RWMutex
+
+// Build the DSN of a translation's file: read-only with a shared cache, plus
+// its configured parameters. Only the whitelisted keys above are accepted, so
+// the DSN holds no credentials and can be logged as is.
+func translationDSN(name, path string) string {
+	query := url.Values{"mode": {"ro"}, "cache": {"shared"}}
+	for key, values := range translationConnParams[name] {
+		if key == "_mmap_size" {
+			continue
+		}
+		query[key] = values
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		mmapMutex.Lock()
+		if size := translationConnParams[name].Get("_mmap_size"); size != "" {
+			mmapSizes[abs] = size
+		} else {
+			delete(mmapSizes, abs)
+		}
+		mmapMutex.Unlock()
+	}
+
+	dsn := fmt.Sprintf("file:%s?%s", path, query.Encode())
+	if size := translationConnParams[name].Get("_mmap_size"); size != "" {
+		log.Printf("Opening %s with DSN %s (mmap_size=%s)", name, dsn, size)
+	} else {
+		log.Printf("Opening %s with DSN %s", name, dsn)
+	}
+	return dsn
+}
+
+// The mmap_size pragma for a connection's database file, if one is configured
+func mmapPragma(filename string) string {
+	mmapMutex.RLock()
+	defer mmapMutex.RUnlock()
+	if size, ok := mmapSizes[filename]; ok {
+		return "PRAGMA mmap_size = " + size
+	}
+	return ""
+}
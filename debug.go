@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type DebugMeta struct {
+	Translation string  `json:"translation,omitempty"`
+	QueryMs     float64 `json:"query_ms"`
+	Cache       string  `json:"cache,omitempty"`
+}
+
+type DebugEnvelope struct {
+	Data json.RawMessage `json:"data"`
+	Meta DebugMeta       `json:"meta"`
+}
+
+// Per-request details collected for ?debug=true
+type debugInfo struct {
+	cache string
+}
+
+type debugContextKey struct{}
+
+// Record whether a cached lookup hit; any miss during the request reports "miss"
+func recordCacheResult(ctx context.Context, hit bool) {
+	info, ok := ctx.Value(debugContextKey{}).(*debugInfo)
+	if !ok {
+		return
+	}
+	if !hit {
+		info.cache = "miss"
+	} else if info.cache == "" {
+		info.cache = "hit"
+	}
+}
+
+// Response writer buffering the body so it can be wrapped afterwards
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if b.status == 0 {
+		b.status = statusCode
+	}
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// Debug envelope middleware: with ?debug=true, successful JSON responses are
+// wrapped as {"data": ..., "meta": {...}}; everything else passes through unchanged.
+func debugMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("debug") != "true" {
+			next(w, r)
+			return
+		}
+
+		info := &debugInfo{}
+		r = r.WithContext(context.WithValue(r.Context(), debugContextKey{}, info))
+		buffered := &bufferedResponseWriter{ResponseWriter: w}
+
+		start := time.Now()
+		next(buffered, r)
+		elapsed := time.Since(start)
+
+		if buffered.status == 0 {
+			buffered.status = http.StatusOK
+		}
+
+		isJSON := strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+		if !isJSON || buffered.status >= 400 || !json.Valid(buffered.body.Bytes()) {
+			w.WriteHeader(buffered.status)
+			w.Write(buffered.body.Bytes())
+			return
+		}
+
+		meta := DebugMeta{
+			Translation: r.PathValue("translation"),
+			QueryMs:     float64(elapsed.Microseconds()) / 1000,
+			Cache:       info.cache,
+		}
+		writeJSON(w, buffered.status, DebugEnvelope{Data: json.RawMessage(buffered.body.Bytes()), Meta: meta})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+type ReadinessResponse struct {
+	Status       string            `json:"status"`
+	Translations []string          `json:"translations"`
+	Degraded     map[string]string `json:"degraded,omitempty"`
+}
+
+// How often a degraded translation is reopened
+const reopenInterval = 5 * time.Second
+
+// Translations taken out of service after their file turned unreadable,
+// with the error that caused it (guarded by dbMutex)
+var degradedTranslations = make(map[string]string)
+
+// Check whether a query error means the file itself is unreadable, as when a
+// database is replaced on disk while open
+func isCorruptionError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrCorrupt || sqliteErr.Code == sqlite3.ErrNotADB) {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "file is not a database") || strings.Contains(message, "malformed")
+}
+
+// Log a query error; a corrupted database is taken out of service so its
+// endpoints answer 503 until a background reopen succeeds
+func logQueryError(translationName string, err error) {
+	log.Printf("Database query error for %s: %v", translationName, err)
+	if isCorruptionError(err) {
+		markDegraded(translationName, err)
+	}
+}
+
+// Remove a translation from the pool and start reopening it
+func markDegraded(translationName string, cause error) {
+	dbMutex.Lock()
+	db, loaded := dbPool[translationName]
+	if loaded {
+		delete(dbPool, translationName)
+		degradedTranslations[translationName] = cause.Error()
+	}
+	dbMutex.Unlock()
+
+	if !loaded {
+		return
+	}
+	log.Printf("Error: Database %s is unreadable, marked unavailable: %v", translationName, cause)
+	go func() {
+		// Close waits for in-flight queries on the old handle
+		db.Close()
+		reopenDatabase(translationName)
+	}()
+}
+
+// Retry opening a degraded translation until it passes the load checks again.
+// Stops early once a reload has replaced the pool.
+func reopenDatabase(translationName string) {
+	path := translations[translationName]
+	for {
+		time.Sleep(reopenInterval)
+
+		dbMutex.RLock()
+		_, stillDegraded := degradedTranslations[translationName]
+		dbMutex.RUnlock()
+		if !stillDegraded {
+			return
+		}
+
+		db, err := openDatabase(translationName, path)
+		if err == nil {
+			err = db.Ping()
+			if err == nil {
+				err = validateSchema(db)
+			}
+			if err != nil {
+				db.Close()
+			}
+		}
+		if err != nil {
+			log.Printf("Warning: Reopening database %s failed: %v", translationName, err)
+			continue
+		}
+
+		// The file may have been replaced, so its checksum, version and
+		// metadata are read again rather than kept from the old one
+		data := loadTranslationData(translationName, path, db)
+
+		dbMutex.Lock()
+		if _, stillDegraded := degradedTranslations[translationName]; !stillDegraded {
+			dbMutex.Unlock()
+			db.Close()
+			return
+		}
+		delete(degradedTranslations, translationName)
+		dbPool[translationName] = db
+		translationLanguages[translationName] = data.language
+		translationMetadata[translationName] = data.meta
+		bookNames[translationName] = data.bookIndex
+		dbMutex.Unlock()
+
+		resetCaches()
+		log.Printf("Database %s reopened (version %s)", translationName, data.meta.Version)
+		return
+	}
+}
+
+// Readiness endpoint: 503 while no translation is loaded or any is degraded
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	dbMutex.RLock()
+	loaded := make([]string, 0, len(dbPool))
+	for name := range dbPool {
+		loaded = append(loaded, name)
+	}
+	degraded := make(map[string]string, len(degradedTranslations))
+	for name, cause := range degradedTranslations {
+		degraded[name] = cause
+	}
+	dbMutex.RUnlock()
+	sort.Strings(loaded)
+
+	status, code := "ready", http.StatusOK
+	if len(degraded) > 0 || len(loaded) == 0 {
+		status, code = "degraded", http.StatusServiceUnavailable
+	}
+	if inMaintenance() {
+		status, code = "maintenance", http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, ReadinessResponse{
+		Status:       status,
+		Translations: loaded,
+		Degraded:     degraded,
+	})
+}
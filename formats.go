@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Sentence boundary: terminal punctuation (optionally followed by a closing quote) and whitespace
+var sentenceBoundaryRegex = regexp.MustCompile(`([.!?]["'”’)]?)\s+`)
+
+// Pause inserted between sentences in SSML output
+const ssmlSentenceBreak = `<break strength="medium"/>`
+
+// Remove all markup, including the tags clearText keeps for display
+func plainText(text string) string {
+	plain := searchMarkupRegex.ReplaceAllString(text, "")
+	return whitespaceRegex.ReplaceAllString(strings.TrimSpace(plain), " ")
+}
+
+// Convert cleaned verse text to SSML for text-to-speech engines
+func toSSML(text string) string {
+	escaped := html.EscapeString(plainText(text))
+
+	withBreaks := sentenceBoundaryRegex.ReplaceAllString(escaped, "$1"+ssmlSentenceBreak+" ")
+	return "<speak>" + withBreaks + "</speak>"
+}
+
+// Word-wrap text at width runes; words longer than a line are split
+func wrapText(text string, width int) []string {
+	return wrapWords(strings.Fields(text), width)
+}
+
+// Wrap pre-split words (a word may contain spaces to keep it on one line)
+func wrapWords(words []string, width int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range words {
+		runes := []rune(word)
+		for len(runes) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		if len(line) > 0 && len(line)+1+len(runes) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, runes...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// Words ending in a period that do not end a sentence
+var sentenceAbbreviations = map[string]bool{
+	"mr.": true, "mrs.": true, "dr.": true, "st.": true, "cf.": true, "vs.": true,
+	"ch.": true, "ver.": true, "e.g.": true, "i.e.": true, "etc.": true,
+	"т.е.": true, "т.д.": true, "т.п.": true, "гл.": true, "ст.": true, "см.": true,
+}
+
+// Closing punctuation that stays attached to the end of a sentence
+const sentenceClosers = `"'”’»)]`
+
+// Split text into sentence fragments.
+//
+// Rules:
+//   - a fragment ends after '.', '!' or '?' (plus any closing quotes or
+//     brackets) that is followed by whitespace or the end of the text;
+//   - a period after a known abbreviation does not end a fragment (single
+//     letters are not treated as initials: "Here am I." ends a sentence);
+//   - when clauses is true, ';' and ':' followed by whitespace also end a
+//     fragment, matching the clause structure of KJV/RST verses
+//     (a colon inside a reference such as 3:16 is never a boundary).
+func splitSentences(text string, clauses bool) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		terminal := ch == '.' || ch == '!' || ch == '?'
+		if !terminal && !(clauses && (ch == ';' || ch == ':')) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(sentenceClosers, runes[end]) {
+			end++
+		}
+		if end < len(runes) && !unicode.IsSpace(runes[end]) {
+			continue
+		}
+		if ch == '.' && isAbbreviation(runes[start:i+1]) {
+			continue
+		}
+
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+		start = end
+		i = end - 1
+	}
+
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// Check whether the text ends in a known abbreviation
+func isAbbreviation(text []rune) bool {
+	fields := strings.Fields(string(text))
+	if len(fields) == 0 {
+		return false
+	}
+	word := strings.ToLower(strings.TrimLeft(fields[len(fields)-1], sentenceClosers+"(["))
+	return sentenceAbbreviations[word]
+}
+
+// A Strong's number or any other markup tag in stored verse text
+var htmlTokenRegex = regexp.MustCompile(`<S>(\d+)</S>|<(/?)([A-Za-z]+)\s*/?>|<[^<>]*>`)
+
+// Source tags converted to semantic HTML; other tags are dropped, keeping their text.
+// <S>n</S> becomes <span class="strongs" data-num="Hn">n</span> (G in the New
+// Testament) and <br/> a line break; <pb/> paragraph breaks become <p> elements.
+var htmlTags = map[string]struct{ open, close string }{
+	"i": {"<em>", "</em>"},
+	"J": {`<span class="wj">`, "</span>"},
+	"e": {"<strong>", "</strong>"},
+}
+
+// Convert the stored markup of a verse to HTML, escaping its text and closing
+// any element the verse leaves open
+func verseHTML(raw string, book int) string {
+	prefix := "G"
+	if book < 470 {
+		prefix = "H"
+	}
+
+	var out strings.Builder
+	var open []string
+	last := 0
+	for _, m := range htmlTokenRegex.FindAllStringSubmatchIndex(raw, -1) {
+		out.WriteString(html.EscapeString(raw[last:m[0]]))
+		last = m[1]
+
+		switch {
+		case m[2] >= 0:
+			num := raw[m[2]:m[3]]
+			fmt.Fprintf(&out, `<span class="strongs" data-num="%s%s">%s</span>`, prefix, num, num)
+		case m[6] >= 0:
+			name := raw[m[6]:m[7]]
+			if name == "br" {
+				out.WriteString("<br>")
+				continue
+			}
+			tag, known := htmlTags[name]
+			if !known {
+				continue
+			}
+			if m[5] > m[4] {
+				// Closing tag: only close what this verse opened
+				if n := len(open); n > 0 && open[n-1] == name {
+					out.WriteString(tag.close)
+					open = open[:n-1]
+				}
+				continue
+			}
+			out.WriteString(tag.open)
+			open = append(open, name)
+		}
+	}
+	out.WriteString(html.EscapeString(raw[last:]))
+
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString(htmlTags[open[i]].close)
+	}
+	return whitespaceRegex.ReplaceAllString(strings.TrimSpace(out.String()), " ")
+}
+
+// Render a chapter as an HTML fragment: a heading and one <p> per paragraph,
+// each verse preceded by its number
+func chapterHTML(chapter *ChapterResponse, language, direction string) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, `<article class="chapter" lang="%s" dir="%s">`+"\n", html.EscapeString(language), html.EscapeString(direction))
+	fmt.Fprintf(&out, "<h1>%s %d</h1>\n", html.EscapeString(chapter.BookTitle), chapter.Chapter)
+	for _, paragraph := range groupParagraphs(chapter.Verses) {
+		out.WriteString("<p>")
+		for i, verse := range paragraph {
+			if i > 0 {
+				out.WriteString(" ")
+			}
+			number := strconv.Itoa(verse.Verse)
+			out.WriteString(`<sup class="verse" id="v` + number + `">` + number + "</sup> ")
+			out.WriteString(verseHTML(verse.rawText, chapter.BookNumber))
+		}
+		out.WriteString("</p>\n")
+	}
+	out.WriteString("</article>\n")
+	return out.String()
+}
+
+// Respond with an HTML fragment
+func respondWithHTML(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(body))
+}
+
+// Respond with plain text
+func respondWithPlainText(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(text))
+}
+
+// Columns of CSV verse output
+var csvHeader = []string{"book_number", "chapter", "verse", "text"}
+
+// CSV row of one verse
+func csvRow(book, chapter, verse int, text string) []string {
+	return []string{strconv.Itoa(book), strconv.Itoa(chapter), strconv.Itoa(verse), text}
+}
+
+// Respond with verse rows as a CSV download with a header row; encoding/csv
+// quotes text containing commas, quotes or line breaks
+func respondWithCSV(w http.ResponseWriter, filename string, rows [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set(headerContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	writer.WriteAll(rows)
+}
+
+// Respond with an SSML document
+func respondWithSSML(w http.ResponseWriter, text string) {
+	w.Header().Set("Content-Type", "application/ssml+xml; charset=utf-8")
+	w.Write([]byte(toSSML(text)))
+}
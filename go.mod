@@ -1,5 +1,10 @@
 module bible-api
 
-go 1.21
+go 1.22
 
-require github.com/mattn/go-sqlite3 v1.14.22
\ No newline at end of file
+require (
+	github.com/andybalholm/brotli v1.2.5
+	github.com/mattn/go-sqlite3 v1.14.22
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/text v0.14.0
+)
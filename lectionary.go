@@ -0,0 +1,418 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LectionaryReading struct {
+	Reference string         `json:"reference"`
+	Verses    []PassageVerse `json:"verses"`
+}
+
+type LectionaryReadings struct {
+	OldTestament *LectionaryReading `json:"old_testament"`
+	Psalm        *LectionaryReading `json:"psalm"`
+	Epistle      *LectionaryReading `json:"epistle"`
+	Gospel       *LectionaryReading `json:"gospel"`
+}
+
+type LectionaryResponse struct {
+	Lectionary  string             `json:"lectionary"`
+	Date        string             `json:"date"`
+	Day         string             `json:"day"`
+	Year        string             `json:"year"`
+	Translation string             `json:"translation"`
+	Readings    LectionaryReadings `json:"readings"`
+}
+
+// Lectionary used when ?lectionary is omitted
+const defaultLectionary = "rcl"
+
+// Readings appointed for one day, in the order OT, Psalm, Epistle, Gospel.
+// In Eastertide the first reading comes from Acts, as the lectionary appoints.
+type lectionarySet [4]string
+
+// A lectionary names the day for a date and returns its readings
+type lectionary struct {
+	resolve func(date time.Time) (day, year string, readings lectionarySet, ok bool)
+}
+
+// Built-in lectionaries by name
+var lectionaries = map[string]lectionary{
+	"rcl": {resolve: resolveRCL},
+}
+
+// Abbreviations used in the reading tables, mapped to MyBible book numbers
+var lectionaryBooks = map[string]int{
+	"Gen": 10, "Exod": 20, "Num": 40, "Deut": 50, "Josh": 60, "1 Sam": 90, "2 Sam": 100,
+	"Ps": 230, "Prov": 240, "Isa": 290, "Jer": 300, "Ezek": 330, "Joel": 360, "Mic": 400,
+	"Zeph": 430, "Mal": 460, "Matt": 470, "Mark": 480, "Luke": 490, "John": 500, "Acts": 510,
+	"Rom": 520, "1 Cor": 530, "2 Cor": 540, "Eph": 560, "Phil": 570, "Col": 580, "1 Thess": 590,
+	"Titus": 630, "Heb": 650, "Jas": 660, "1 Pet": 670, "2 Pet": 680, "1 John": 690, "Rev": 730,
+}
+
+// Revised Common Lectionary readings for the principal days of the year,
+// keyed by day and then by year of the three-year cycle ("*" for every year)
+var rclReadings = map[string]map[string]lectionarySet{
+	"Advent 1": {
+		"A": {"Isa 2:1-5", "Ps 122", "Rom 13:11-14", "Matt 24:36-44"},
+		"B": {"Isa 64:1-9", "Ps 80:1-7, 17-19", "1 Cor 1:3-9", "Mark 13:24-37"},
+		"C": {"Jer 33:14-16", "Ps 25:1-10", "1 Thess 3:9-13", "Luke 21:25-36"},
+	},
+	"Advent 2": {
+		"A": {"Isa 11:1-10", "Ps 72:1-7, 18-19", "Rom 15:4-13", "Matt 3:1-12"},
+		"B": {"Isa 40:1-11", "Ps 85:1-2, 8-13", "2 Pet 3:8-15", "Mark 1:1-8"},
+		"C": {"Mal 3:1-4", "Luke 1:68-79", "Phil 1:3-11", "Luke 3:1-6"},
+	},
+	"Advent 3": {
+		"A": {"Isa 35:1-10", "Ps 146:5-10", "Jas 5:7-10", "Matt 11:2-11"},
+		"B": {"Isa 61:1-4, 8-11", "Ps 126", "1 Thess 5:16-24", "John 1:6-8, 19-28"},
+		"C": {"Zeph 3:14-20", "Isa 12:2-6", "Phil 4:4-7", "Luke 3:7-18"},
+	},
+	"Advent 4": {
+		"A": {"Isa 7:10-16", "Ps 80:1-7, 17-19", "Rom 1:1-7", "Matt 1:18-25"},
+		"B": {"2 Sam 7:1-11, 16", "Luke 1:46-55", "Rom 16:25-27", "Luke 1:26-38"},
+		"C": {"Mic 5:2-5", "Luke 1:46-55", "Heb 10:5-10", "Luke 1:39-45"},
+	},
+	"Christmas Day": {
+		"*": {"Isa 9:2-7", "Ps 96", "Titus 2:11-14", "Luke 2:1-20"},
+	},
+	"Epiphany": {
+		"*": {"Isa 60:1-6", "Ps 72:1-7, 10-14", "Eph 3:1-12", "Matt 2:1-12"},
+	},
+	"Ash Wednesday": {
+		"*": {"Joel 2:1-2, 12-17", "Ps 51:1-17", "2 Cor 5:20-6:10", "Matt 6:1-6, 16-21"},
+	},
+	"Lent 1": {
+		"A": {"Gen 2:15-17; 3:1-7", "Ps 32", "Rom 5:12-19", "Matt 4:1-11"},
+		"B": {"Gen 9:8-17", "Ps 25:1-10", "1 Pet 3:18-22", "Mark 1:9-15"},
+		"C": {"Deut 26:1-11", "Ps 91:1-2, 9-16", "Rom 10:8-13", "Luke 4:1-13"},
+	},
+	"Lent 2": {
+		"A": {"Gen 12:1-4", "Ps 121", "Rom 4:1-5, 13-17", "John 3:1-17"},
+		"B": {"Gen 17:1-7, 15-16", "Ps 22:23-31", "Rom 4:13-25", "Mark 8:31-38"},
+		"C": {"Gen 15:1-12, 17-18", "Ps 27", "Phil 3:17-4:1", "Luke 13:31-35"},
+	},
+	"Lent 3": {
+		"A": {"Exod 17:1-7", "Ps 95", "Rom 5:1-11", "John 4:5-42"},
+		"B": {"Exod 20:1-17", "Ps 19", "1 Cor 1:18-25", "John 2:13-22"},
+		"C": {"Isa 55:1-9", "Ps 63:1-8", "1 Cor 10:1-13", "Luke 13:1-9"},
+	},
+	"Lent 4": {
+		"A": {"1 Sam 16:1-13", "Ps 23", "Eph 5:8-14", "John 9:1-41"},
+		"B": {"Num 21:4-9", "Ps 107:1-3, 17-22", "Eph 2:1-10", "John 3:14-21"},
+		"C": {"Josh 5:9-12", "Ps 32", "2 Cor 5:16-21", "Luke 15:1-3, 11-32"},
+	},
+	"Lent 5": {
+		"A": {"Ezek 37:1-14", "Ps 130", "Rom 8:6-11", "John 11:1-45"},
+		"B": {"Jer 31:31-34", "Ps 51:1-12", "Heb 5:5-10", "John 12:20-33"},
+		"C": {"Isa 43:16-21", "Ps 126", "Phil 3:4-14", "John 12:1-8"},
+	},
+	"Palm Sunday": {
+		"A": {"Isa 50:4-9", "Ps 31:9-16", "Phil 2:5-11", "Matt 26:14-27:66"},
+		"B": {"Isa 50:4-9", "Ps 31:9-16", "Phil 2:5-11", "Mark 14:1-15:47"},
+		"C": {"Isa 50:4-9", "Ps 31:9-16", "Phil 2:5-11", "Luke 22:14-23:56"},
+	},
+	"Easter Day": {
+		"A": {"Acts 10:34-43", "Ps 118:1-2, 14-24", "Col 3:1-4", "John 20:1-18"},
+		"B": {"Acts 10:34-43", "Ps 118:1-2, 14-24", "1 Cor 15:1-11", "John 20:1-18"},
+		"C": {"Acts 10:34-43", "Ps 118:1-2, 14-24", "1 Cor 15:19-26", "John 20:1-18"},
+	},
+	"Easter 2": {
+		"A": {"Acts 2:14, 22-32", "Ps 16", "1 Pet 1:3-9", "John 20:19-31"},
+		"B": {"Acts 4:32-35", "Ps 133", "1 John 1:1-2:2", "John 20:19-31"},
+		"C": {"Acts 5:27-32", "Ps 150", "Rev 1:4-8", "John 20:19-31"},
+	},
+	"Easter 3": {
+		"A": {"Acts 2:14, 36-41", "Ps 116:1-4, 12-19", "1 Pet 1:17-23", "Luke 24:13-35"},
+		"B": {"Acts 3:12-19", "Ps 4", "1 John 3:1-7", "Luke 24:36-48"},
+		"C": {"Acts 9:1-20", "Ps 30", "Rev 5:11-14", "John 21:1-19"},
+	},
+	"Easter 4": {
+		"A": {"Acts 2:42-47", "Ps 23", "1 Pet 2:19-25", "John 10:1-10"},
+		"B": {"Acts 4:5-12", "Ps 23", "1 John 3:16-24", "John 10:11-18"},
+		"C": {"Acts 9:36-43", "Ps 23", "Rev 7:9-17", "John 10:22-30"},
+	},
+	"Easter 5": {
+		"A": {"Acts 7:55-60", "Ps 31:1-5, 15-16", "1 Pet 2:2-10", "John 14:1-14"},
+		"B": {"Acts 8:26-40", "Ps 22:25-31", "1 John 4:7-21", "John 15:1-8"},
+		"C": {"Acts 11:1-18", "Ps 148", "Rev 21:1-6", "John 13:31-35"},
+	},
+	"Easter 6": {
+		"A": {"Acts 17:22-31", "Ps 66:8-20", "1 Pet 3:13-22", "John 14:15-21"},
+		"B": {"Acts 10:44-48", "Ps 98", "1 John 5:1-6", "John 15:9-17"},
+		"C": {"Acts 16:9-15", "Ps 67", "Rev 21:10, 22-27; 22:1-5", "John 14:23-29"},
+	},
+	"Easter 7": {
+		"A": {"Acts 1:6-14", "Ps 68:1-10, 32-35", "1 Pet 4:12-14; 5:6-11", "John 17:1-11"},
+		"B": {"Acts 1:15-17, 21-26", "Ps 1", "1 John 5:9-13", "John 17:6-19"},
+		"C": {"Acts 16:16-34", "Ps 97", "Rev 22:12-14, 16-17, 20-21", "John 17:20-26"},
+	},
+	"Pentecost": {
+		"A": {"Acts 2:1-21", "Ps 104:24-35", "1 Cor 12:3-13", "John 20:19-23"},
+		"B": {"Acts 2:1-21", "Ps 104:24-35", "Rom 8:22-27", "John 15:26-27; 16:4-15"},
+		"C": {"Acts 2:1-21", "Ps 104:24-35", "Rom 8:14-17", "John 14:8-17"},
+	},
+	"Trinity Sunday": {
+		"A": {"Gen 1:1-2:4", "Ps 8", "2 Cor 13:11-13", "Matt 28:16-20"},
+		"B": {"Isa 6:1-8", "Ps 29", "Rom 8:12-17", "John 3:1-17"},
+		"C": {"Prov 8:1-4, 22-31", "Ps 8", "Rom 5:1-5", "John 16:12-15"},
+	},
+	"Christ the King": {
+		"A": {"Ezek 34:11-16, 20-24", "Ps 100", "Eph 1:15-23", "Matt 25:31-46"},
+		"B": {"2 Sam 23:1-7", "Ps 132:1-12", "Rev 1:4-8", "John 18:33-37"},
+		"C": {"Jer 23:1-6", "Luke 1:68-79", "Col 1:11-20", "Luke 23:33-43"},
+	},
+}
+
+// Days named relative to Easter Sunday
+var rclEasterOffsets = map[int]string{
+	-46: "Ash Wednesday",
+	-42: "Lent 1", -35: "Lent 2", -28: "Lent 3", -21: "Lent 4", -14: "Lent 5",
+	-7: "Palm Sunday", 0: "Easter Day",
+	7: "Easter 2", 14: "Easter 3", 21: "Easter 4", 28: "Easter 5", 35: "Easter 6", 42: "Easter 7",
+	49: "Pentecost", 56: "Trinity Sunday",
+}
+
+// Days named relative to the first Sunday of Advent
+var rclAdventOffsets = map[int]string{
+	-7: "Christ the King",
+	0:  "Advent 1", 7: "Advent 2", 14: "Advent 3", 21: "Advent 4",
+}
+
+// Western Easter Sunday for a year (anonymous Gregorian algorithm)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// First Sunday of Advent: the Sunday falling between November 27 and December 3
+func adventSunday(year int) time.Time {
+	date := time.Date(year, time.November, 27, 0, 0, 0, 0, time.UTC)
+	return date.AddDate(0, 0, (7-int(date.Weekday()))%7)
+}
+
+// Whole days from one midnight UTC date to another
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
+
+// Name the RCL day for a date and pick its readings for the year of the cycle
+func resolveRCL(date time.Time) (string, string, lectionarySet, bool) {
+	advent := adventSunday(date.Year())
+
+	// The liturgical year begins on Advent 1; year A starts in years divisible by 3
+	liturgicalYear := date.Year()
+	if !date.Before(advent) {
+		liturgicalYear++
+	}
+	year := [3]string{"C", "A", "B"}[liturgicalYear%3]
+
+	var day string
+	switch {
+	case date.Month() == time.December && date.Day() == 25:
+		day = "Christmas Day"
+	case date.Month() == time.January && date.Day() == 6:
+		day = "Epiphany"
+	default:
+		if name, ok := rclEasterOffsets[daysBetween(easterSunday(date.Year()), date)]; ok {
+			day = name
+		} else if name, ok := rclAdventOffsets[daysBetween(advent, date)]; ok {
+			day = name
+		}
+	}
+
+	byYear, exists := rclReadings[day]
+	if !exists {
+		return "", year, lectionarySet{}, false
+	}
+	if readings, ok := byYear[year]; ok {
+		return day, year, readings, true
+	}
+	readings, ok := byYear["*"]
+	return day, year, readings, ok
+}
+
+// Book abbreviation followed by the chapter and verse ranges
+var lectionaryReferenceRegex = regexp.MustCompile(`^((?:[123] )?[A-Za-z]+) (.+)$`)
+
+// Contiguous range of verses within one book
+type passageRange struct {
+	fromChapter, fromVerse, toChapter, toVerse int
+}
+
+// Parse a reading such as "Gen 2:15-17; 3:1-7" or "Ps 80:1-7, 17-19" into
+// its book and verse ranges. Segments separated by ";" name their chapter;
+// a segment without a colon is a whole chapter.
+func parseLectionaryReference(reference string) (int, []passageRange, error) {
+	match := lectionaryReferenceRegex.FindStringSubmatch(reference)
+	if match == nil {
+		return 0, nil, fmt.Errorf("malformed reference %q", reference)
+	}
+	book, exists := lectionaryBooks[match[1]]
+	if !exists {
+		return 0, nil, fmt.Errorf("unknown book %q", match[1])
+	}
+
+	var ranges []passageRange
+	for _, segment := range strings.Split(match[2], ";") {
+		chapter := 0
+		for i, piece := range strings.Split(segment, ",") {
+			piece = strings.TrimSpace(piece)
+			if i == 0 && !strings.Contains(piece, ":") {
+				number, err := strconv.Atoi(piece)
+				if err != nil {
+					return 0, nil, fmt.Errorf("malformed chapter %q in %q", piece, reference)
+				}
+				ranges = append(ranges, passageRange{number, 1, number, math.MaxInt32})
+				continue
+			}
+
+			start, end, _ := strings.Cut(piece, "-")
+			if chapterText, verseText, found := strings.Cut(start, ":"); found {
+				number, err := strconv.Atoi(chapterText)
+				if err != nil {
+					return 0, nil, fmt.Errorf("malformed chapter %q in %q", chapterText, reference)
+				}
+				chapter, start = number, verseText
+			}
+			if chapter == 0 {
+				return 0, nil, fmt.Errorf("missing chapter in %q", reference)
+			}
+			fromVerse, err := strconv.Atoi(start)
+			if err != nil {
+				return 0, nil, fmt.Errorf("malformed verse %q in %q", start, reference)
+			}
+
+			current := passageRange{chapter, fromVerse, chapter, fromVerse}
+			if end != "" {
+				if chapterText, verseText, found := strings.Cut(end, ":"); found {
+					number, err := strconv.Atoi(chapterText)
+					if err != nil {
+						return 0, nil, fmt.Errorf("malformed chapter %q in %q", chapterText, reference)
+					}
+					current.toChapter, chapter, end = number, number, verseText
+				}
+				if current.toVerse, err = strconv.Atoi(end); err != nil {
+					return 0, nil, fmt.Errorf("malformed verse %q in %q", end, reference)
+				}
+			}
+			ranges = append(ranges, current)
+		}
+	}
+	return book, ranges, nil
+}
+
+// Resolve a reading to the text of its verses
+func fetchLectionaryReading(ctx context.Context, db *sql.DB, reference string) (*LectionaryReading, error) {
+	book, ranges, err := parseLectionaryReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	reading := &LectionaryReading{Reference: reference, Verses: make([]PassageVerse, 0)}
+	for _, current := range ranges {
+		verses, err := fetchPassage(ctx, db, book, current.fromChapter, current.fromVerse, current.toChapter, current.toVerse, false, -1)
+		if err != nil {
+			return nil, err
+		}
+		reading.Verses = append(reading.Verses, verses...)
+	}
+	return reading, nil
+}
+
+// Get the readings appointed for a date handler
+func lectionaryHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	name := params.Get("lectionary")
+	if name == "" {
+		name = defaultLectionary
+	}
+	selected, exists := lectionaries[name]
+	if !exists {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("Lectionary '%s' not found", name), http.StatusNotFound)
+		return
+	}
+
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := params.Get("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	}
+
+	// Default to the first loaded translation in name order
+	translationName := params.Get("translation")
+	if translationName == "" {
+		dbMutex.RLock()
+		names := make([]string, 0, len(dbPool))
+		for loaded := range dbPool {
+			names = append(names, loaded)
+		}
+		dbMutex.RUnlock()
+		if len(names) == 0 {
+			respondWithError(w, errCodeDatabaseUnavailable, "No translations are available", http.StatusServiceUnavailable)
+			return
+		}
+		sort.Strings(names)
+		translationName = names[0]
+	}
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	day, year, readings, ok := selected.resolve(date)
+	if !ok {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("No readings appointed for %s in lectionary '%s'", date.Format("2006-01-02"), name), http.StatusNotFound)
+		return
+	}
+
+	response := LectionaryResponse{
+		Lectionary:  name,
+		Date:        date.Format("2006-01-02"),
+		Day:         day,
+		Year:        year,
+		Translation: translationName,
+	}
+	slots := []**LectionaryReading{
+		&response.Readings.OldTestament,
+		&response.Readings.Psalm,
+		&response.Readings.Epistle,
+		&response.Readings.Gospel,
+	}
+	for i, reference := range readings {
+		reading, err := fetchLectionaryReading(r.Context(), db, reference)
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve readings", http.StatusInternalServerError)
+			return
+		}
+		*slots[i] = reading
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
-
-	_ "github.com/mattn/go-sqlite3"
+	"time"
 )
 
 // Translation configuration
@@ -20,10 +27,37 @@ var translations = map[string]string{
 	"RST": "assets/RST+.Sqlite3",
 }
 
+// Build version, set with -ldflags "-X main.version=..."
+var version = "dev"
+
+// When the process started, for the uptime reported by /health
+var startTime time.Time
+
+// Translations to open (ENABLED_TRANSLATIONS); nil opens every configured one
+var enabledTranslations map[string]bool
+
 // Database connection pool for each translation
 var dbPool = make(map[string]*sql.DB)
 var dbMutex sync.RWMutex
 
+// Language tag of each loaded translation (from the module's info table)
+var translationLanguages = make(map[string]string)
+
+// Per-translation verse counts by book
+var bookCountCache = make(map[string][]BookVerseCount)
+var bookCountMutex sync.Mutex
+
+// Hardening options (off by default to keep existing deployments unchanged)
+var securityHeadersEnabled = false
+
+// Supported values for TLS_MIN_VERSION
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
 // Response structures
 type VerseResponse struct {
 	Translation     string `json:"translation"`
@@ -33,43 +67,105 @@ type VerseResponse struct {
 	Chapter         int    `json:"chapter"`
 	Verse           int    `json:"verse"`
 	Text            string `json:"text"`
+	GlobalIndex     *int   `json:"global_index,omitempty"`
+	Language        string `json:"language,omitempty"`
+	Direction       string `json:"direction,omitempty"`
+	FallbackUsed    bool   `json:"fallback_used,omitempty"`
+	RowID           *int64 `json:"rowid,omitempty"`
+	Transliteration string `json:"transliteration,omitempty"`
+
+	// Text as stored, kept for requests that choose their own transforms
+	rawText string
+	// SQLite rowid of the verse, exposed via include=rowid
+	rowID int64
+}
+
+type Reference struct {
+	Book    int `json:"book"`
+	Chapter int `json:"chapter"`
+	Verse   int `json:"verse"`
+}
+
+type CrossReference struct {
+	BookNumber int    `json:"book_number"`
+	Chapter    int    `json:"chapter"`
+	VerseStart int    `json:"verse_start"`
+	VerseEnd   int    `json:"verse_end"`
+	Votes      int    `json:"votes"`
+	Text       string `json:"text,omitempty"`
+}
+
+type CrossReferencesResponse struct {
+	Translation     string           `json:"translation"`
+	BookNumber      int              `json:"book_number"`
+	Chapter         int              `json:"chapter"`
+	Verse           int              `json:"verse"`
+	CrossReferences []CrossReference `json:"cross_references"`
+}
+
+type BookVerseCount struct {
+	BookNumber int `json:"book_number"`
+	Verses     int `json:"verses"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code"`
 }
 
-// Regex for cleaning text (matches Python version)
-var textCleanRegex = regexp.MustCompile(`(<S>\d+</S>|</?[^ai <>]+/?>)`)
+// Machine-readable error codes sent alongside error messages
+const (
+	errCodeTranslationNotFound = "translation_not_found"
+	errCodeBookNotFound        = "book_not_found"
+	errCodeVerseNotFound       = "verse_not_found"
+	errCodeNotFound            = "not_found"
+	errCodeInvalidReference    = "invalid_reference"
+	errCodeInvalidParameter    = "invalid_parameter"
+	errCodeInvalidBody         = "invalid_body"
+	errCodeBodyTooLarge        = "body_too_large"
+	errCodeDatabaseUnavailable = "db_unavailable"
+	errCodeInternal            = "internal_error"
+	errCodeMethodNotAllowed    = "method_not_allowed"
+	errCodeRateLimited         = "rate_limited"
+	errCodeOverloaded          = "overloaded"
+	errCodeTimeout             = "timeout"
+	errCodeUnauthorized        = "unauthorized"
+	errCodeForbidden           = "forbidden"
+	errCodeMaintenance         = "maintenance"
+)
+
 var whitespaceRegex = regexp.MustCompile(`\s+`)
 
-// Clean text function (Python equivalent)
+// Clean text function (Python equivalent): the default transforms
 func clearText(text string) string {
-	cleaned := textCleanRegex.ReplaceAllString(text, "")
-	cleaned = strings.TrimSpace(cleaned)
-	cleaned = whitespaceRegex.ReplaceAllString(cleaned, " ")
-	return cleaned
+	return applyTransforms(text, defaultTransforms)
 }
 
 // Initialize database connections
 func initDatabases() error {
+	pool := make(map[string]*sql.DB)
+	languages := make(map[string]string)
+	metadata := make(map[string]translationMeta)
+	names := make(map[string]map[string]int)
+
 	for name, path := range translations {
+		if enabledTranslations != nil && !enabledTranslations[name] {
+			log.Printf("Skipping translation %s (not in ENABLED_TRANSLATIONS)", name)
+			continue
+		}
+
 		// Check if file exists
 		if _, err := os.Stat(path); os.IsNotExist(err) {
 			log.Printf("Warning: Database file not found for %s: %s", name, path)
 			continue
 		}
 
-		// Open database with read-only and connection pooling
-		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro&cache=shared", path))
+		db, err := openDatabase(name, path)
 		if err != nil {
+			closeDatabases(pool)
 			return fmt.Errorf("failed to open database %s: %v", name, err)
 		}
 
-		// Set connection pool settings for concurrent reads
-		db.SetMaxOpenConns(25)
-		db.SetMaxIdleConns(5)
-
 		// Test connection
 		if err := db.Ping(); err != nil {
 			db.Close()
@@ -77,87 +173,507 @@ func initDatabases() error {
 			continue
 		}
 
-		dbPool[name] = db
+		// Reject files that are not in the expected format before serving them
+		if err := validateSchema(db); err != nil {
+			db.Close()
+			log.Printf("Error: Database %s (%s) has an invalid schema, skipping: %v", name, path, err)
+			continue
+		}
+
+		data := loadTranslationData(name, path, db)
+		pool[name] = db
+		names[name] = data.bookIndex
+		languages[name] = data.language
+		metadata[name] = data.meta
 		log.Printf("Successfully connected to %s database", name)
 	}
 
-	if len(dbPool) == 0 {
+	if len(pool) == 0 {
 		return fmt.Errorf("no valid databases could be loaded")
 	}
 
+	// Swap in the new connections; on reload the previous ones are closed
+	// once in-flight queries finish
+	dbMutex.Lock()
+	previous := dbPool
+	dbPool = pool
+	degradedTranslations = make(map[string]string)
+	translationLanguages = languages
+	translationMetadata = metadata
+	bookNames = names
+	dbMutex.Unlock()
+
+	closeDatabases(previous)
+	resetCaches()
+
 	return nil
 }
 
+// Per-file data served alongside a translation's connections
+type translationData struct {
+	language  string
+	bookIndex map[string]int
+	meta      translationMeta
+}
+
+// Read the per-file data of a freshly opened database
+func loadTranslationData(name, path string, db *sql.DB) translationData {
+	// Checksum the file once so deployments can verify the exact version
+	checksum, err := fileChecksum(path)
+	if err != nil {
+		log.Printf("Warning: Failed to checksum database %s: %v", name, err)
+	}
+
+	bookIndex, err := loadBookNames(db)
+	if err != nil {
+		log.Printf("Warning: Failed to read book names for %s: %v", name, err)
+	}
+
+	language := detectLanguage(db)
+	return translationData{
+		language:  language,
+		bookIndex: bookIndex,
+		meta: translationMeta{
+			Description: readInfoValue(db, "description"),
+			SHA256:      checksum,
+			Direction:   detectDirection(db, language),
+			Translit:    detectTranslit(db),
+			Version:     translationVersion(path, checksum),
+		},
+	}
+}
+
+// Open a database read-only with connection pooling
+func openDatabase(name, path string) (*sql.DB, error) {
+	db, err := sql.Open(sqliteDriverName, translationDSN(name, path))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set connection pool settings for concurrent reads
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(5)
+	return db, nil
+}
+
+// Close every connection in a pool
+func closeDatabases(pool map[string]*sql.DB) {
+	for name, db := range pool {
+		log.Printf("Closing database connection for %s", name)
+		db.Close()
+	}
+}
+
+// Drop data cached from the previous set of databases
+func resetCaches() {
+	bookCountMutex.Lock()
+	bookCountCache = make(map[string][]BookVerseCount)
+	bookCountMutex.Unlock()
+
+	outlineMutex.Lock()
+	outlineCache = make(map[string][]OutlineBook)
+	outlineMutex.Unlock()
+
+	verseLengthsMutex.Lock()
+	verseLengthsCache = make(map[string]*VerseLengthsResponse)
+	verseLengthsMutex.Unlock()
+
+	tocMutex.Lock()
+	tocCache = make(map[string][]byte)
+	tocMutex.Unlock()
+
+	chapterJSONCache.clear()
+}
+
 // Get random verse handler
 func getRandomVerseHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract translation name from URL path
-	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	if len(parts) != 2 {
-		respondWithError(w, "Invalid URL format", http.StatusBadRequest)
+	translationName := r.PathValue("translation")
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
 		return
 	}
 
-	translationName := parts[1]
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// Check if translation exists in configuration
-	if _, exists := translations[translationName]; !exists {
-		respondWithError(w, fmt.Sprintf("Translation '%s' not found", translationName), http.StatusNotFound)
+	// Parse optional book weights (book_number:weight,...)
+	var weights map[int]float64
+	if value := r.URL.Query().Get("weights"); value != "" {
+		weights, err = parseBookWeights(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	transforms, err := parseTransforms(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strongs, err := parseStrongs(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Get database connection
-	dbMutex.RLock()
-	db, exists := dbPool[translationName]
-	dbMutex.RUnlock()
+	excluded, err := parseReferenceList(translationName, r.URL.Query().Get("exclude"), maxExcludedVerses)
+	if err != nil {
+		respondWithError(w, errCodeInvalidReference, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if !exists {
-		respondWithError(w, fmt.Sprintf("Database for translation '%s' is not available", translationName), http.StatusServiceUnavailable)
+	// With weights, choose the book first and then a random verse within it
+	book := 0
+	if weights != nil {
+		counts, err := store.BookVerseCounts(r.Context())
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+			return
+		}
+		book, err = pickWeightedBook(counts, weights)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	verse, err := store.RandomVerse(r.Context(), book, excluded)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	if verse == nil {
+		respondWithError(w, errCodeVerseNotFound, "No verse left to choose from", http.StatusNotFound)
 		return
 	}
+	if transforms != nil || strongs != strongsStrip {
+		verse.Text = renderText(verse.rawText, verse.BookNumber, transforms, strongs)
+	}
+
+	if err := applyIncludes(r.Context(), store, verse, includes); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+
+	// Return JSON response
+	writeJSON(w, http.StatusOK, verse)
+}
+
+// Maximum number of references accepted in ?exclude
+const maxExcludedVerses = 100
 
-	// Execute query
-	var verse VerseResponse
-	var rawText string
+// Parse a book weight list such as "470:3,480:3"
+func parseBookWeights(value string) (map[int]float64, error) {
+	weights := make(map[int]float64)
+	for _, entry := range strings.Split(value, ",") {
+		bookPart, weightPart, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return nil, fmt.Errorf("Invalid weight '%s', expected book_number:weight", entry)
+		}
+		book, err := strconv.Atoi(bookPart)
+		if err != nil || book < 1 {
+			return nil, fmt.Errorf("Invalid book number '%s' in weights", bookPart)
+		}
+		weight, err := strconv.ParseFloat(weightPart, 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return nil, fmt.Errorf("Invalid weight '%s' for book %d", weightPart, book)
+		}
+		if _, duplicate := weights[book]; duplicate {
+			return nil, fmt.Errorf("Duplicate weight for book %d", book)
+		}
+		weights[book] = weight
+	}
+	return weights, nil
+}
+
+// Pick a book with probability proportional to its verse count times its weight.
+// Books without an explicit weight keep a weight of 1, so the unweighted
+// distribution matches uniform selection over all verses.
+func pickWeightedBook(counts []BookVerseCount, weights map[int]float64) (int, error) {
+	known := make(map[int]bool, len(counts))
+	for _, c := range counts {
+		known[c.BookNumber] = true
+	}
+	for book := range weights {
+		if !known[book] {
+			return 0, fmt.Errorf("Book %d not found in translation", book)
+		}
+	}
+
+	total := 0.0
+	for _, c := range counts {
+		total += float64(c.Verses) * bookWeightOf(weights, c.BookNumber)
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("Weights must select at least one book")
+	}
+
+	target := rand.Float64() * total
+	for _, c := range counts {
+		target -= float64(c.Verses) * bookWeightOf(weights, c.BookNumber)
+		if target < 0 {
+			return c.BookNumber, nil
+		}
+	}
+
+	// Floating point rounding: fall back to the last book with a positive weight
+	for i := len(counts) - 1; i >= 0; i-- {
+		if bookWeightOf(weights, counts[i].BookNumber) > 0 {
+			return counts[i].BookNumber, nil
+		}
+	}
+	return 0, fmt.Errorf("Weights must select at least one book")
+}
+
+func bookWeightOf(weights map[int]float64, book int) float64 {
+	if weight, ok := weights[book]; ok {
+		return weight
+	}
+	return 1
+}
+
+// Get per-book verse counts, cached per translation since the databases are read-only
+func getBookVerseCounts(ctx context.Context, translationName string, db *sql.DB) ([]BookVerseCount, error) {
+	bookCountMutex.Lock()
+	defer bookCountMutex.Unlock()
+
+	if counts, ok := bookCountCache[translationName]; ok {
+		recordCacheResult(ctx, true)
+		return counts, nil
+	}
+	recordCacheResult(ctx, false)
+
+	rows, err := db.Query("SELECT book_number, COUNT(*) FROM verses GROUP BY book_number ORDER BY book_number")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []BookVerseCount
+	for rows.Next() {
+		var c BookVerseCount
+		if err := rows.Scan(&c.BookNumber, &c.Verses); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	bookCountCache[translationName] = counts
+	return counts, nil
+}
+
+// Drop a translation's cached verse counts so the next lookup recounts them
+func forgetBookVerseCounts(translationName string) {
+	bookCountMutex.Lock()
+	delete(bookCountCache, translationName)
+	bookCountMutex.Unlock()
+}
+
+// Cross-reference tables follow the MyBible layout
+const crossReferenceTable = "cross_references"
+
+// Get cross-references for a single verse
+func getCrossReferencesHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	supported, err := hasTable(db, crossReferenceTable)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve cross-references", http.StatusInternalServerError)
+		return
+	}
+	if !supported {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("Translation '%s' has no cross-references", translationName), http.StatusNotFound)
+		return
+	}
+
+	includeText := r.URL.Query().Get("include_text") == "true"
 
 	query := `
-		SELECT v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
-		FROM verses v
-		JOIN books b ON v.book_number = b.book_number
-		ORDER BY RANDOM()
-		LIMIT 1
+		SELECT book_to, chapter_to, verse_to_start, verse_to_end, votes
+		FROM cross_references
+		WHERE book = ? AND chapter = ?
+			AND ? BETWEEN verse AND CASE WHEN verse_end > 0 THEN verse_end ELSE verse END
+		ORDER BY votes DESC, book_to, chapter_to, verse_to_start
 	`
 
-	err := db.QueryRow(query).Scan(
-		&verse.BookNumber,
-		&verse.Chapter,
-		&verse.Verse,
-		&rawText,
-		&verse.BookTitleShort,
-		&verse.BookTitle,
-	)
+	rows, err := db.Query(query, ref.Book, ref.Chapter, ref.Verse)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve cross-references", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	crossRefs := make([]CrossReference, 0)
+	for rows.Next() {
+		var crossRef CrossReference
+		if err := rows.Scan(&crossRef.BookNumber, &crossRef.Chapter, &crossRef.VerseStart, &crossRef.VerseEnd, &crossRef.Votes); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve cross-references", http.StatusInternalServerError)
+			return
+		}
+		if crossRef.VerseEnd < crossRef.VerseStart {
+			crossRef.VerseEnd = crossRef.VerseStart
+		}
+		crossRefs = append(crossRefs, crossRef)
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve cross-references", http.StatusInternalServerError)
+		return
+	}
 
+	// Optionally resolve the text of each referenced passage
+	if includeText {
+		for i := range crossRefs {
+			text, err := passageText(db, crossRefs[i])
+			if err != nil {
+				logQueryError(translationName, err)
+				respondWithError(w, errCodeInternal, "Failed to retrieve cross-references", http.StatusInternalServerError)
+				return
+			}
+			crossRefs[i].Text = text
+		}
+	}
+
+	writeJSON(w, http.StatusOK, CrossReferencesResponse{
+		Translation:     translationName,
+		BookNumber:      ref.Book,
+		Chapter:         ref.Chapter,
+		Verse:           ref.Verse,
+		CrossReferences: crossRefs,
+	})
+}
+
+// Concatenate the cleaned text of a referenced passage
+func passageText(db *sql.DB, ref CrossReference) (string, error) {
+	rows, err := db.Query(
+		"SELECT text FROM verses WHERE book_number = ? AND chapter = ? AND verse BETWEEN ? AND ? ORDER BY verse",
+		ref.BookNumber, ref.Chapter, ref.VerseStart, ref.VerseEnd,
+	)
 	if err != nil {
-		log.Printf("Database query error for %s: %v", translationName, err)
-		respondWithError(w, "Failed to retrieve verse", http.StatusInternalServerError)
+		return "", err
+	}
+	defer rows.Close()
+
+	texts := make([]string, 0, ref.VerseEnd-ref.VerseStart+1)
+	for rows.Next() {
+		var rawText string
+		if err := rows.Scan(&rawText); err != nil {
+			return "", err
+		}
+		texts = append(texts, clearText(rawText))
+	}
+	return strings.Join(texts, " "), rows.Err()
+}
+
+// Parse the book/chapter/verse path values declared by the route.
+// The book may be a number or a name of the route's translation.
+func pathReference(r *http.Request) (Reference, error) {
+	var ref Reference
+	if segment := r.PathValue("book"); segment != "" {
+		book, err := parseBook(r.PathValue("translation"), segment)
+		if err != nil {
+			return ref, err
+		}
+		ref.Book = book
+	}
+
+	values := []*int{&ref.Chapter, &ref.Verse}
+	names := []string{"chapter", "verse"}
+	for i, name := range names {
+		segment := r.PathValue(name)
+		if segment == "" {
+			continue
+		}
+		n, err := strconv.Atoi(segment)
+		if err != nil || n < 1 {
+			return ref, fmt.Errorf("Invalid %s number '%s'", name, segment)
+		}
+		*values[i] = n
+	}
+	return ref, nil
+}
+
+// Respond to a pathReference error: unknown book names are 404, anything else 400
+func respondWithReferenceError(w http.ResponseWriter, err error) {
+	var notFound *bookNotFoundError
+	if errors.As(err, &notFound) {
+		respondWithError(w, errCodeBookNotFound, err.Error(), http.StatusNotFound)
 		return
 	}
+	respondWithError(w, errCodeInvalidReference, err.Error(), http.StatusBadRequest)
+}
 
-	// Clean text and set translation name
-	verse.Text = clearText(rawText)
-	verse.Translation = translationName
+// Check whether a table exists in the database
+func hasTable(db *sql.DB, name string) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)
+	return count > 0, err
+}
 
-	// Return JSON response
+// Resolve a translation to its database, responding with an error if unavailable
+func lookupDatabase(w http.ResponseWriter, translationName string) (*sql.DB, bool) {
+	// Check if translation exists in configuration
+	if _, exists := translations[translationName]; !exists {
+		respondWithError(w, errCodeTranslationNotFound, fmt.Sprintf("Translation '%s' not found", translationName), http.StatusNotFound)
+		return nil, false
+	}
+
+	// Get database connection
+	dbMutex.RLock()
+	db, exists := dbPool[translationName]
+	version := translationMetadata[translationName].Version
+	dbMutex.RUnlock()
+
+	if !exists {
+		respondWithError(w, errCodeDatabaseUnavailable, fmt.Sprintf("Database for translation '%s' is not available", translationName), http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	// Let caches key on the data version so a replaced file busts them
+	if translationVersionHeader && version != "" {
+		w.Header().Set(headerTranslationVersion, version)
+	}
+
+	return db, true
+}
+
+// Write a JSON response; HTML escaping is disabled so text such as "<", ">"
+// and "&" reaches clients unchanged
+func writeJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 	encoder := json.NewEncoder(w)
 	encoder.SetEscapeHTML(false)
-	encoder.Encode(verse)
+	encoder.Encode(payload)
 }
 
 // Helper function to respond with errors
-func respondWithError(w http.ResponseWriter, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+func respondWithError(w http.ResponseWriter, code, message string, statusCode int) {
+	writeJSON(w, statusCode, ErrorResponse{Error: message, Code: code})
 }
 
 // Health check endpoint
@@ -169,34 +685,154 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	dbMutex.RUnlock()
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":       "ok",
-		"translations": availableTranslations,
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":         "ok",
+		"translations":   availableTranslations,
+		"uptime_seconds": int64(time.Since(startTime).Seconds()),
+		"version":        version,
+		"maintenance":    inMaintenance(),
 	})
 }
 
+// Message returned for unknown routes (NOT_FOUND_MESSAGE)
+var notFoundMessage = "Not found"
+
+// Ping endpoint for uptime monitors (no locking or database access)
+func pingHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("pong"))
+}
+
+// Trust X-Forwarded-For / X-Real-IP only behind a reverse proxy (TRUST_PROXY)
+var trustProxyHeaders = false
+
+// Get the client IP without port. Forwarded headers are honoured only when
+// trustProxyHeaders is set; X-Forwarded-For contributes its first hop.
+func clientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return stripPort(ip)
+			}
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return stripPort(ip)
+		}
+	}
+	return stripPort(r.RemoteAddr)
+}
+
+// Remove the port from host:port or [ipv6]:port; bare addresses are returned as is
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
 // Logging middleware
 func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip := r.Header.Get("X-Real-IP")
-		if ip == "" {
-			ip = r.Header.Get("X-Forwarded-For")
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next(recorder, r)
+
+		// Errors are always logged; successful requests only at the sample rate
+		if recorder.status < 400 && logSampleRate < 1 && rand.Float64() >= logSampleRate {
+			return
+		}
+		log.Printf("[%s] %s from %s -> %d (%s)", r.Method, r.URL.Path, clientIP(r), recorder.status, time.Since(start).Round(time.Microsecond))
+	}
+}
+
+// Fraction of successful requests that are logged (LOG_SAMPLE_RATE)
+var logSampleRate = 1.0
+
+// Response writer that remembers the status code sent
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.status = statusCode
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+// Expose the wrapped writer so http.ResponseController can reach Flush
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Maximum accepted request body size in bytes (MAX_BODY_BYTES)
+var maxBodyBytes int64 = 64 << 10
+
+// Request body size limit middleware
+func bodyLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBodyBytes {
+			respondWithError(w, errCodeBodyTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBodyBytes), http.StatusRequestEntityTooLarge)
+			return
 		}
-		if ip == "" {
-			ip = r.RemoteAddr
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
 		}
-		log.Printf("[%s] %s from %s", r.Method, r.URL.Path, ip)
 		next(w, r)
 	}
 }
 
+// Decode a JSON request body, responding with 413 or 400 on failure
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}, invalidMessage string) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, errCodeBodyTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return false
+		}
+		respondWithError(w, errCodeInvalidBody, invalidMessage, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// Response headers set by the API beyond the CORS-safelisted ones
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+	headerContentDisposition = "Content-Disposition"
+	headerTranslationVersion = "X-Translation-Version"
+)
+
+// Headers browsers may read from cross-origin responses; set only through
+// the constants above so the list stays accurate
+var exposedHeaders = strings.Join([]string{
+	headerRateLimitLimit,
+	headerRateLimitRemaining,
+	headerRateLimitReset,
+	headerRetryAfter,
+	headerContentDisposition,
+	headerTranslationVersion,
+}, ", ")
+
 // CORS middleware
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
 		
 		// Handle preflight requests
 		if r.Method == "OPTIONS" {
@@ -208,24 +844,307 @@ func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// Security headers middleware
+func securityHeadersMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Frame-Options", "DENY")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		}
+		next(w, r)
+	}
+}
+
+// Wrap a handler with the per-route middleware chain (CORS and security
+// headers are applied to the whole router)
+func withMiddleware(h http.HandlerFunc) http.HandlerFunc {
+	h = debugMiddleware(h)
+	if limiter != nil {
+		h = rateLimitMiddleware(limiter, h)
+	}
+	h = bodyLimitMiddleware(h)
+	if requestSlots != nil {
+		h = concurrencyLimitMiddleware(h)
+	}
+	h = clientCountMiddleware(h)
+	return loggingMiddleware(h)
+}
+
+// Apply the stricter limiter to an expensive handler
+func withExpensiveLimit(h http.HandlerFunc) http.HandlerFunc {
+	if expensiveLimiter == nil {
+		return h
+	}
+	return rateLimitMiddleware(expensiveLimiter, h)
+}
+
+// Cache-Control sent by random endpoints (RANDOM_CACHE_CONTROL); no-store keeps
+// CDNs from serving one "random" verse to everyone
+var randomCacheControl = "no-store"
+
+// Mark a handler's responses as different on every request
+func withRandomCacheControl(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", randomCacheControl)
+		h(w, r)
+	}
+}
+
+// Parse TLS_MIN_VERSION, defaulting to TLS 1.2
+func parseTLSMinVersion(value string) (uint16, error) {
+	if value == "" {
+		return tls.VersionTLS12, nil
+	}
+	version, ok := tlsVersions[value]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q (expected 1.0, 1.1, 1.2 or 1.3)", value)
+	}
+	return version, nil
+}
+
 func main() {
+	startTime = time.Now()
+
+	// Restrict which configured translations are opened
+	if value := os.Getenv("ENABLED_TRANSLATIONS"); value != "" {
+		enabledTranslations = make(map[string]bool)
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if _, exists := translations[name]; !exists {
+				log.Fatalf("Invalid ENABLED_TRANSLATIONS value %q: unknown translation %q", value, name)
+			}
+			enabledTranslations[name] = true
+		}
+	}
+
+	// Read per-translation connection parameters
+	for name := range translations {
+		if value := os.Getenv("DB_PARAMS_" + name); value != "" {
+			params, err := parseConnParams(value)
+			if err != nil {
+				log.Fatalf("Invalid DB_PARAMS_%s value %q: %v", name, value, err)
+			}
+			translationConnParams[name] = params
+		}
+	}
+
+	if value := os.Getenv("TRANSLATION_VERSION_HEADER"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid TRANSLATION_VERSION_HEADER value %q: %v", value, err)
+		}
+		translationVersionHeader = enabled
+	}
+
 	// Initialize databases
 	log.Println("Initializing databases...")
 	if err := initDatabases(); err != nil {
 		log.Fatalf("Failed to initialize databases: %v", err)
 	}
 
+	// Preload caches in the background unless a fast start is preferred
+	if value := os.Getenv("WARM_UP"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid WARM_UP value %q: %v", value, err)
+		}
+		warmUpEnabled = enabled
+	}
+	if warmUpEnabled {
+		warmUpCaches()
+	}
+
+	// Load the curated verse-of-the-day list, warning about references the
+	// translations lack
+	if path := os.Getenv("VOTD_FILE"); path != "" {
+		refs, err := loadVOTDFile(path)
+		if err != nil {
+			log.Fatalf("Invalid VOTD_FILE %q: %v", path, err)
+		}
+		validateVOTDReferences(refs)
+		votdReferences = refs
+		log.Printf("Loaded %d verse-of-the-day references from %s", len(refs), path)
+	}
+
 	// Defer closing all database connections
 	defer func() {
-		for name, db := range dbPool {
-			log.Printf("Closing database connection for %s", name)
-			db.Close()
-		}
+		dbMutex.Lock()
+		closeDatabases(dbPool)
+		dbMutex.Unlock()
 	}()
 
+	// Read sitemap splitting
+	if value := os.Getenv("SITEMAP_INDEX"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid SITEMAP_INDEX value %q: %v", value, err)
+		}
+		sitemapIndexEnabled = enabled
+	}
+
+	// Read hardening options
+	if value := os.Getenv("SECURITY_HEADERS"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid SECURITY_HEADERS value %q: %v", value, err)
+		}
+		securityHeadersEnabled = enabled
+	}
+
+	// Read strict query parameter checking
+	if value := os.Getenv("STRICT_PARAMS"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid STRICT_PARAMS value %q: %v", value, err)
+		}
+		strictParamsEnabled = enabled
+	}
+
+	// Read proxy trust
+	if value := os.Getenv("TRUST_PROXY"); value != "" {
+		trusted, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid TRUST_PROXY value %q: %v", value, err)
+		}
+		trustProxyHeaders = trusted
+	}
+
+	// Read request log sampling
+	if value := os.Getenv("LOG_SAMPLE_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			log.Fatalf("Invalid LOG_SAMPLE_RATE value %q (expected 0.0 to 1.0)", value)
+		}
+		logSampleRate = rate
+	}
+
+	// Read load shedding options
+	if value := os.Getenv("MAX_CONCURRENT_REQUESTS"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			log.Fatalf("Invalid MAX_CONCURRENT_REQUESTS value %q", value)
+		}
+		if limit > 0 {
+			requestSlots = make(chan struct{}, limit)
+		}
+	}
+	if value := os.Getenv("MAX_CONCURRENT_WAIT"); value != "" {
+		wait, err := time.ParseDuration(value)
+		if err != nil || wait < 0 {
+			log.Fatalf("Invalid MAX_CONCURRENT_WAIT value %q", value)
+		}
+		concurrencyWait = wait
+	}
+	if value := os.Getenv("NORMALIZE_NFC"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid NORMALIZE_NFC value %q: %v", value, err)
+		}
+		if !enabled {
+			delete(defaultTransforms, "normalize_nfc")
+		}
+	}
+	publicBaseURL = os.Getenv("PUBLIC_BASE_URL")
+	if value := os.Getenv("CLEAN_PATTERNS"); value != "" {
+		patterns, err := parseCleanPatterns(value)
+		if err != nil {
+			log.Fatalf("Invalid CLEAN_PATTERNS value %q: %v", value, err)
+		}
+		customCleanPatterns = patterns
+	}
+	if value := os.Getenv("RANDOM_CACHE_CONTROL"); value != "" {
+		randomCacheControl = value
+	}
+	if value := os.Getenv("CHAPTER_CACHE_SIZE"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			log.Fatalf("Invalid CHAPTER_CACHE_SIZE value %q", value)
+		}
+		chapterJSONCache = newChapterCache(size)
+	}
+	if value := os.Getenv("REQUEST_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			log.Fatalf("Invalid REQUEST_TIMEOUT value %q", value)
+		}
+		requestTimeout = timeout
+	}
+	if value := os.Getenv("CONCURRENCY_RETRY_AFTER"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			log.Fatalf("Invalid CONCURRENCY_RETRY_AFTER value %q", value)
+		}
+		concurrencyRetryAfter = seconds
+	}
+
+	// Read request body limit
+	if value := os.Getenv("MAX_BODY_BYTES"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 1 {
+			log.Fatalf("Invalid MAX_BODY_BYTES value %q", value)
+		}
+		maxBodyBytes = limit
+	}
+
+	// Admin endpoints are disabled unless a token is configured
+	adminToken = os.Getenv("ADMIN_TOKEN")
+
+	// Brotli is offered only when enabled
+	if value := os.Getenv("BROTLI"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid BROTLI value %q: %v", value, err)
+		}
+		brotliEnabled = enabled
+	}
+
+	// Read compression level; invalid values fall back to the default
+	if value := os.Getenv("GZIP_LEVEL"); value != "" {
+		level, err := strconv.Atoi(value)
+		if err != nil || level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			log.Printf("Warning: Invalid GZIP_LEVEL %q (expected %d to %d), using %d", value, gzip.HuffmanOnly, gzip.BestCompression, gzipLevel)
+		} else {
+			gzipLevel = level
+		}
+	}
+
+	// Read rate limit options (requests per minute per client IP)
+	if value := os.Getenv("RATE_LIMIT_RPM"); value != "" {
+		perMinute, err := strconv.Atoi(value)
+		if err != nil || perMinute < 0 {
+			log.Fatalf("Invalid RATE_LIMIT_RPM value %q", value)
+		}
+		burst := perMinute
+		if value := os.Getenv("RATE_LIMIT_BURST"); value != "" {
+			burst, err = strconv.Atoi(value)
+			if err != nil || burst < 1 {
+				log.Fatalf("Invalid RATE_LIMIT_BURST value %q", value)
+			}
+		}
+		if perMinute > 0 {
+			limiter = newRateLimiter(perMinute, burst)
+			log.Printf("Rate limiting enabled: %d requests/minute, burst %d", perMinute, burst)
+		}
+	}
+	if value := os.Getenv("REGEX_RATE_LIMIT_RPM"); value != "" {
+		perMinute, err := strconv.Atoi(value)
+		if err != nil || perMinute < 0 {
+			log.Fatalf("Invalid REGEX_RATE_LIMIT_RPM value %q", value)
+		}
+		expensiveLimiter = nil
+		if perMinute > 0 {
+			expensiveLimiter = newRateLimiter(perMinute, perMinute)
+		}
+	}
+
 	// Setup routes
-	http.HandleFunc("/get-random-verse/", corsMiddleware(loggingMiddleware(getRandomVerseHandler)))
-	http.HandleFunc("/health", corsMiddleware(loggingMiddleware(healthHandler)))
+	if message := os.Getenv("NOT_FOUND_MESSAGE"); message != "" {
+		notFoundMessage = message
+	}
+	router := newRouter()
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -233,7 +1152,14 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Starting server on port %s...", port)
+	// Bind address defaults to all interfaces; BIND_ADDR takes precedence over HOST
+	host := os.Getenv("BIND_ADDR")
+	if host == "" {
+		host = os.Getenv("HOST")
+	}
+	addr := net.JoinHostPort(host, port)
+
+	log.Printf("Starting server on %s...", addr)
 	log.Printf("Available translations: %v", func() []string {
 		keys := make([]string, 0, len(dbPool))
 		for k := range dbPool {
@@ -242,7 +1168,28 @@ func main() {
 		return keys
 	}())
 
-	if err := http.ListenAndServe(":"+port, nil); err != nil {
+	server := &http.Server{Addr: addr, Handler: router}
+
+	// Serve over TLS when a certificate and key are configured
+	certFile := os.Getenv("TLS_CERT_FILE")
+	keyFile := os.Getenv("TLS_KEY_FILE")
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			log.Fatalf("Both TLS_CERT_FILE and TLS_KEY_FILE must be set to enable TLS")
+		}
+		minVersion, err := parseTLSMinVersion(os.Getenv("TLS_MIN_VERSION"))
+		if err != nil {
+			log.Fatalf("Invalid TLS_MIN_VERSION: %v", err)
+		}
+		server.TLSConfig = &tls.Config{MinVersion: minVersion}
+		log.Printf("TLS enabled (minimum version %s)", tls.VersionName(minVersion))
+		if err := server.ListenAndServeTLS(certFile, keyFile); err != nil {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+		return
+	}
+
+	if err := server.ListenAndServe(); err != nil {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
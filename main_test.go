@@ -0,0 +1,204 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A verse row of a test database
+type testVerse struct {
+	book, chapter, verse int
+	text                 string
+}
+
+// A few verses of Genesis 1
+var testGenesis = []testVerse{
+	{10, 1, 1, "In the beginning God created the heaven and the earth."},
+	{10, 1, 2, "And the earth was without form, and void."},
+	{10, 1, 3, "And God said, Let there be light: and there was light."},
+}
+
+// Create a MyBible-style database file holding verses and return its path
+func newTestDatabase(t *testing.T, language string, verses []testVerse) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.SQLite3")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	statements := []string{
+		"CREATE TABLE books (book_color TEXT, book_number INTEGER, short_name TEXT, long_name TEXT)",
+		"CREATE TABLE info (name TEXT, value TEXT)",
+		"CREATE TABLE verses (book_number NUMERIC, chapter NUMERIC, verse NUMERIC, text TEXT)",
+		"CREATE UNIQUE INDEX verses_index ON verses (book_number, chapter, verse)",
+		"INSERT INTO books VALUES ('#ccccff', 10, 'Gen', 'Genesis'), ('#ccccff', 20, 'Exo', 'Exodus'), ('#ffcccc', 500, 'John', 'John')",
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO info VALUES ('language', ?), ('description', 'Test')", language); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range verses {
+		if _, err := db.Exec("INSERT INTO verses VALUES (?, ?, ?, ?)", v.book, v.chapter, v.verse, v.text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// Serve the given translation files in place of the configured ones for one test
+func loadTestTranslations(t *testing.T, paths map[string]string) {
+	t.Helper()
+	saved := translations
+	translations = paths
+	t.Cleanup(func() {
+		dbMutex.Lock()
+		closeDatabases(dbPool)
+		dbPool = make(map[string]*sql.DB)
+		dbMutex.Unlock()
+		resetCaches()
+		translations = saved
+	})
+	if err := initDatabases(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Send a request through the full router
+func serve(method, target, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body == "" {
+		req = httptest.NewRequest(method, target, nil)
+	} else {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+	}
+	rec := httptest.NewRecorder()
+	newRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+// Decode an error response, failing the test unless it has the expected status and code
+func assertError(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, status, rec.Body)
+	}
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body is not JSON: %v (%s)", err, rec.Body)
+	}
+	if body.Code != code || body.Error == "" {
+		t.Fatalf("error = %+v, want code %q with a message", body, code)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	saved := maxBodyBytes
+	maxBodyBytes = 64
+	t.Cleanup(func() { maxBodyBytes = saved })
+
+	body := "[" + strings.Repeat(`{"book":10,"chapter":1,"verse":1},`, 10) + `{"book":10,"chapter":1,"verse":1}]`
+
+	t.Run("declared length", func(t *testing.T) {
+		assertError(t, serve(http.MethodPost, "/batch/KJV", body), http.StatusRequestEntityTooLarge, errCodeBodyTooLarge)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		// Without a Content-Length the limit is only hit while decoding
+		req := httptest.NewRequest(http.MethodPost, "/batch/KJV", strings.NewReader(body))
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, req)
+		assertError(t, rec, http.StatusRequestEntityTooLarge, errCodeBodyTooLarge)
+	})
+}
+
+func TestErrorStatusCodes(t *testing.T) {
+	broken := newTestDatabase(t, "en", testGenesis)
+	loadTestTranslations(t, map[string]string{
+		"TEST":   newTestDatabase(t, "en", testGenesis),
+		"EMPTY":  newTestDatabase(t, "en", nil),
+		"BROKEN": broken,
+		"GONE":   filepath.Join(t.TempDir(), "missing.SQLite3"),
+	})
+
+	// A file that passed the load checks but can no longer be queried
+	db, err := sql.Open("sqlite3", broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("DROP TABLE books"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+		code   string
+		allow  string
+	}{
+		{"unknown translation", http.MethodGet, "/get-verse/NOPE/10/1/1", http.StatusNotFound, errCodeTranslationNotFound, ""},
+		{"unknown book", http.MethodGet, "/get-verse/TEST/Judith/1/1", http.StatusNotFound, errCodeBookNotFound, ""},
+		{"malformed chapter", http.MethodGet, "/get-verse/TEST/10/one/1", http.StatusBadRequest, errCodeInvalidReference, ""},
+		{"missing verse", http.MethodGet, "/get-verse/TEST/10/1/99", http.StatusNotFound, errCodeVerseNotFound, ""},
+		{"missing chapter", http.MethodGet, "/get-chapter/TEST/10/50", http.StatusNotFound, errCodeVerseNotFound, ""},
+		{"no verse to pick", http.MethodGet, "/get-random-verse/EMPTY", http.StatusNotFound, errCodeVerseNotFound, ""},
+		{"database not loaded", http.MethodGet, "/get-verse/GONE/10/1/1", http.StatusServiceUnavailable, errCodeDatabaseUnavailable, ""},
+		{"query failure", http.MethodGet, "/get-verse/BROKEN/10/1/1", http.StatusInternalServerError, errCodeInternal, ""},
+		{"unknown path", http.MethodGet, "/no-such-endpoint", http.StatusNotFound, errCodeNotFound, ""},
+		{"POST to a GET route", http.MethodPost, "/get-verse/TEST/10/1/1", http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "GET"},
+		{"GET to a POST route", http.MethodGet, "/batch/TEST", http.StatusMethodNotAllowed, errCodeMethodNotAllowed, "POST"},
+		{"invalid batch body", http.MethodPost, "/batch/TEST", http.StatusBadRequest, errCodeInvalidBody, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := ""
+			if tt.method == http.MethodPost {
+				body = "not json"
+			}
+			rec := serve(tt.method, tt.target, body)
+			assertError(t, rec, tt.status, tt.code)
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestWriteJSONDoesNotEscape(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeJSON(rec, http.StatusCreated, map[string]string{"text": "Light — <i>and</i> dark & void"})
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	want := `{"text":"Light — <i>and</i> dark & void"}` + "\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestErrorResponsesDoNotEscape(t *testing.T) {
+	rec := httptest.NewRecorder()
+	respondWithError(rec, errCodeInvalidParameter, "Expected <book> — got &", http.StatusBadRequest)
+
+	if body := rec.Body.String(); !strings.Contains(body, `"Expected <book> — got &"`) {
+		t.Errorf("body = %q, want the message unescaped", body)
+	}
+}
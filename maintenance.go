@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Maintenance mode, toggled through /admin/maintenance; while it is on the
+// data endpoints answer 503
+var maintenanceMode = false
+var maintenanceMutex sync.RWMutex
+
+func inMaintenance() bool {
+	maintenanceMutex.RLock()
+	defer maintenanceMutex.RUnlock()
+	return maintenanceMode
+}
+
+// Operational routes stay available during maintenance
+func maintenanceExempt(pattern string) bool {
+	return pattern == "/health" || pattern == "/readyz" || pattern == "/metrics" || strings.HasPrefix(pattern, "/admin/")
+}
+
+// Reject requests with 503 while maintenance mode is on
+func maintenanceMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if inMaintenance() {
+			respondWithError(w, errCodeMaintenance, "The API is under maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Switch maintenance mode on or off handler
+func adminMaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if !decodeJSONBody(w, r, &body, `Request body must be {"enabled": true|false}`) {
+		return
+	}
+	if body.Enabled == nil {
+		respondWithError(w, errCodeInvalidBody, `Request body must be {"enabled": true|false}`, http.StatusBadRequest)
+		return
+	}
+
+	maintenanceMutex.Lock()
+	maintenanceMode = *body.Enabled
+	maintenanceMutex.Unlock()
+	log.Printf("Maintenance mode set to %v", *body.Enabled)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"maintenance": *body.Enabled,
+	})
+}
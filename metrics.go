@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Prometheus metrics endpoint (text exposition format)
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	var out strings.Builder
+
+	entries, hits, misses, evictions := chapterJSONCache.stats()
+	writeMetric(&out, "bible_api_chapter_cache_entries", "gauge", "Chapter responses held in the cache.", entries)
+	writeMetric(&out, "bible_api_chapter_cache_hits_total", "counter", "Chapter requests served from the cache.", hits)
+	writeMetric(&out, "bible_api_chapter_cache_misses_total", "counter", "Chapter requests assembled from the database.", misses)
+	writeMetric(&out, "bible_api_chapter_cache_evictions_total", "counter", "Chapter responses evicted to stay within the cache size.", evictions)
+
+	// Connection pool statistics, collected per translation on each scrape
+	dbMutex.RLock()
+	pools := make(map[string]sql.DBStats, len(dbPool))
+	for name, db := range dbPool {
+		pools[name] = db.Stats()
+	}
+	dbMutex.RUnlock()
+
+	poolMetrics := []struct {
+		name, kind, help string
+		value            func(sql.DBStats) interface{}
+	}{
+		{"bible_api_db_max_open_connections", "gauge", "Maximum open connections allowed to the database.", func(s sql.DBStats) interface{} { return s.MaxOpenConnections }},
+		{"bible_api_db_open_connections", "gauge", "Established connections to the database.", func(s sql.DBStats) interface{} { return s.OpenConnections }},
+		{"bible_api_db_in_use_connections", "gauge", "Connections currently in use.", func(s sql.DBStats) interface{} { return s.InUse }},
+		{"bible_api_db_idle_connections", "gauge", "Idle connections.", func(s sql.DBStats) interface{} { return s.Idle }},
+		{"bible_api_db_wait_count_total", "counter", "Connections waited for because the pool was exhausted.", func(s sql.DBStats) interface{} { return s.WaitCount }},
+		{"bible_api_db_wait_duration_seconds_total", "counter", "Time spent waiting for a connection.", func(s sql.DBStats) interface{} { return s.WaitDuration.Seconds() }},
+	}
+	for _, metric := range poolMetrics {
+		values := make(map[string]interface{}, len(pools))
+		for name, stats := range pools {
+			values[name] = metric.value(stats)
+		}
+		writeLabelledMetric(&out, metric.name, metric.kind, metric.help, "translation", values)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(out.String()))
+}
+
+// Write one unlabelled metric with its HELP and TYPE lines
+func writeMetric(out *strings.Builder, name, kind, help string, value interface{}) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+}
+
+// Write a metric with one sample per label value, sorted by label
+func writeLabelledMetric(out *strings.Builder, name, kind, help, label string, values map[string]interface{}) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s{%s=%q} %v\n", name, label, key, values[key])
+	}
+}
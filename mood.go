@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type MoodVersesResponse struct {
+	Translation string          `json:"translation"`
+	Mood        string          `json:"mood"`
+	Verses      []VerseResponse `json:"verses"`
+}
+
+// Keywords per mood and language; a verse matches a mood when its text
+// contains any of them. Add a mood or a language here to extend the endpoint.
+var moodKeywords = map[string]map[string][]string{
+	"comfort": {
+		"en": {"comfort", "refuge", "fear not", "my shepherd", "give you rest", "peace be"},
+		"ru": {"утеш", "прибежищ", "не бойся", "пастырь мой", "успокою вас", "мир вам"},
+	},
+	"hope": {
+		"en": {"hope", "wait upon the lord", "trust in the lord", "promise"},
+		"ru": {"надежд", "уповай", "уповаю", "обетовани"},
+	},
+	"courage": {
+		"en": {"courage", "be strong", "fear not", "be not afraid", "stand fast"},
+		"ru": {"мужайся", "будь тверд", "не бойся", "не бойтесь", "стойте"},
+	},
+	"gratitude": {
+		"en": {"thanks", "thanksgiving", "praise the lord", "bless the lord"},
+		"ru": {"благодар", "хвалите господа", "благослови, душа моя"},
+	},
+}
+
+// Verses returned per mood request
+const (
+	moodDefaultCount = 5
+	moodMaxCount     = 20
+)
+
+// Names of the available moods, sorted
+func moodNames() []string {
+	names := make([]string, 0, len(moodKeywords))
+	for name := range moodKeywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get random verses matching a mood's keywords handler
+func moodVersesHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	mood := strings.ToLower(r.URL.Query().Get("mood"))
+	if mood == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'mood' is required", http.StatusBadRequest)
+		return
+	}
+	languages, exists := moodKeywords[mood]
+	if !exists {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unknown mood '%s', expected one of: %s", mood, strings.Join(moodNames(), ", ")), http.StatusBadRequest)
+		return
+	}
+
+	count, err := parseIntParam(r.URL.Query().Get("count"), moodDefaultCount, 1, moodMaxCount)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid count: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	dbMutex.RLock()
+	language := translationLanguages[translationName]
+	dbMutex.RUnlock()
+
+	base, _, _ := strings.Cut(language, "-")
+	keywords := languages[base]
+	if len(keywords) == 0 {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("Mood '%s' has no keywords for translation '%s'", mood, translationName), http.StatusNotFound)
+		return
+	}
+
+	// Keywords are normalized like the verse text so case and accents do not matter
+	conditions := make([]string, len(keywords))
+	args := make([]interface{}, 0, len(keywords)*3+1)
+	for i, keyword := range keywords {
+		conditions[i] = "normalize_text(v.text, ?, 1) LIKE ? ESCAPE '\\'"
+		args = append(args, language, "%"+escapeLike(normalizeSearchText(keyword, language, true))+"%")
+	}
+	args = append(args, count)
+
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf(`
+		SELECT v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE %s
+		ORDER BY RANDOM()
+		LIMIT ?
+	`, strings.Join(conditions, " OR ")), args...)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	verses := make([]VerseResponse, 0, count)
+	for rows.Next() {
+		var verse VerseResponse
+		var rawText string
+		if err := rows.Scan(&verse.BookNumber, &verse.Chapter, &verse.Verse, &rawText, &verse.BookTitleShort, &verse.BookTitle); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+			return
+		}
+		verse.Text = clearText(rawText)
+		verse.Translation = translationName
+		verses = append(verses, verse)
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, MoodVersesResponse{
+		Translation: translationName,
+		Mood:        mood,
+		Verses:      verses,
+	})
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type ChapterRef struct {
+	Book    int `json:"book"`
+	Chapter int `json:"chapter"`
+}
+
+type ChapterNavResponse struct {
+	Translation string      `json:"translation"`
+	BookNumber  int         `json:"book_number"`
+	Chapter     int         `json:"chapter"`
+	Prev        *ChapterRef `json:"prev"`
+	Next        *ChapterRef `json:"next"`
+}
+
+type AdjacentBookResponse struct {
+	Translation string           `json:"translation"`
+	FromBook    int              `json:"from_book"`
+	Book        BookInfo         `json:"book"`
+	Chapter     *ChapterResponse `json:"chapter,omitempty"`
+}
+
+// Get the previous and next chapters of a chapter, crossing book boundaries
+func chapterNavHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	exists, err := chapterExists(db, ref.Book, ref.Chapter)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter navigation", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Chapter %d:%d not found", ref.Book, ref.Chapter), http.StatusNotFound)
+		return
+	}
+
+	prev, err := adjacentChapter(db, ref.Book, ref.Chapter, false)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter navigation", http.StatusInternalServerError)
+		return
+	}
+	next, err := adjacentChapter(db, ref.Book, ref.Chapter, true)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve chapter navigation", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ChapterNavResponse{
+		Translation: translationName,
+		BookNumber:  ref.Book,
+		Chapter:     ref.Chapter,
+		Prev:        prev,
+		Next:        next,
+	})
+}
+
+// Check whether a chapter has at least one verse
+func chapterExists(db *sql.DB, book, chapter int) (bool, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM verses WHERE book_number = ? AND chapter = ?", book, chapter).Scan(&count)
+	return count > 0, err
+}
+
+// Find the chapter immediately before or after the given one in canonical order
+func adjacentChapter(db *sql.DB, book, chapter int, forward bool) (*ChapterRef, error) {
+	query := `
+		SELECT book_number, chapter FROM verses
+		WHERE book_number < ? OR (book_number = ? AND chapter < ?)
+		ORDER BY book_number DESC, chapter DESC
+		LIMIT 1
+	`
+	if forward {
+		query = `
+			SELECT book_number, chapter FROM verses
+			WHERE book_number > ? OR (book_number = ? AND chapter > ?)
+			ORDER BY book_number, chapter
+			LIMIT 1
+		`
+	}
+
+	var ref ChapterRef
+	err := db.QueryRow(query, book, book, chapter).Scan(&ref.Book, &ref.Chapter)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// Get the book before or after a book handler, optionally with its first chapter.
+// Neighbours follow the books table, which may skip numbers.
+func adjacentBookHandler(forward bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		translationName := r.PathValue("translation")
+		ref, err := pathReference(r)
+		if err != nil {
+			respondWithReferenceError(w, err)
+			return
+		}
+
+		includeChapter := false
+		if value := r.URL.Query().Get("include_chapter"); value != "" {
+			includeChapter, err = strconv.ParseBool(value)
+			if err != nil {
+				respondWithError(w, errCodeInvalidParameter, "Invalid include_chapter value", http.StatusBadRequest)
+				return
+			}
+		}
+		includeApocrypha, err := parseIncludeApocrypha(r)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid include_apocrypha value", http.StatusBadRequest)
+			return
+		}
+
+		db, ok := lookupDatabase(w, translationName)
+		if !ok {
+			return
+		}
+
+		books, err := fetchBooks(db)
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve book", http.StatusInternalServerError)
+			return
+		}
+		canon := books[:0]
+		for _, book := range books {
+			if includeApocrypha || !apocryphaBooks[book.BookNumber] || book.BookNumber == ref.Book {
+				canon = append(canon, book)
+			}
+		}
+
+		position := -1
+		for i, book := range canon {
+			if book.BookNumber == ref.Book {
+				position = i
+				break
+			}
+		}
+		if position < 0 {
+			respondWithError(w, errCodeBookNotFound, fmt.Sprintf("Book %d not found", ref.Book), http.StatusNotFound)
+			return
+		}
+
+		step, direction := -1, "previous"
+		if forward {
+			step, direction = 1, "next"
+		}
+		position += step
+		if position < 0 || position >= len(canon) {
+			respondWithError(w, errCodeNotFound, fmt.Sprintf("Book %d has no %s book", ref.Book, direction), http.StatusNotFound)
+			return
+		}
+
+		response := AdjacentBookResponse{Translation: translationName, FromBook: ref.Book, Book: canon[position]}
+		if includeChapter {
+			response.Chapter, err = firstChapter(r.Context(), db, translationName, response.Book.BookNumber)
+			if err != nil {
+				logQueryError(translationName, err)
+				respondWithError(w, errCodeInternal, "Failed to retrieve book", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// Assemble the first chapter of a book; nil when the book has no verses
+func firstChapter(ctx context.Context, db *sql.DB, translationName string, book int) (*ChapterResponse, error) {
+	var chapter sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT MIN(chapter) FROM verses WHERE book_number = ?", book).Scan(&chapter); err != nil {
+		return nil, err
+	}
+	if !chapter.Valid {
+		return nil, nil
+	}
+	return fetchChapter(ctx, db, translationName, book, int(chapter.Int64))
+}
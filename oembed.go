@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type OEmbedResponse struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	ProviderName string `json:"provider_name"`
+	Title        string `json:"title"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// Default size of the embedded quote, shrunk to fit maxwidth/maxheight
+const (
+	oembedWidth  = 500
+	oembedHeight = 200
+)
+
+// Get an oEmbed document for a verse URL handler
+func oembedHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	if format := params.Get("format"); format != "" && format != "json" {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unsupported format '%s'", format), http.StatusNotImplemented)
+		return
+	}
+
+	width, err := parseIntParam(params.Get("maxwidth"), oembedWidth, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid maxwidth: %v", err), http.StatusBadRequest)
+		return
+	}
+	height, err := parseIntParam(params.Get("maxheight"), oembedHeight, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid maxheight: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Only verse links can be embedded: .../get-verse/{translation}/{book}/{chapter}/{verse}
+	target, err := url.Parse(params.Get("url"))
+	if err != nil || params.Get("url") == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'url' must be a verse URL", http.StatusBadRequest)
+		return
+	}
+	segments := strings.Split(strings.Trim(target.Path, "/"), "/")
+	if len(segments) != 5 || segments[0] != "get-verse" {
+		respondWithError(w, errCodeNotFound, "URL does not point to a verse", http.StatusNotFound)
+		return
+	}
+
+	translationName := segments[1]
+	if _, exists := translations[translationName]; !exists {
+		respondWithError(w, errCodeTranslationNotFound, fmt.Sprintf("Translation '%s' not found", translationName), http.StatusNotFound)
+		return
+	}
+	book, err := parseBook(translationName, segments[2])
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+	chapter, chapterErr := strconv.Atoi(segments[3])
+	verseNumber, verseErr := strconv.Atoi(segments[4])
+	if chapterErr != nil || verseErr != nil || chapter < 1 || verseNumber < 1 {
+		respondWithError(w, errCodeInvalidReference, "URL does not point to a valid verse", http.StatusBadRequest)
+		return
+	}
+	ref := Reference{Book: book, Chapter: chapter, Verse: verseNumber}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := store.GetVerses(r.Context(), []Reference{ref})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	verse := verses[0]
+	if verse == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+
+	title := fmt.Sprintf("%s %d:%d (%s)", verse.BookTitle, verse.Chapter, verse.Verse, translationName)
+	markup := fmt.Sprintf("<blockquote class=\"bible-verse\"><p>%s</p><cite>%s</cite></blockquote>",
+		html.EscapeString(plainText(verse.Text)), html.EscapeString(title))
+
+	writeJSON(w, http.StatusOK, OEmbedResponse{
+		Version:      "1.0",
+		Type:         "rich",
+		ProviderName: "Bible API",
+		Title:        title,
+		HTML:         markup,
+		Width:        min(width, oembedWidth),
+		Height:       min(height, oembedHeight),
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type ParallelVerse struct {
+	VerseResponse
+	Similarity float64 `json:"similarity"`
+}
+
+type ParallelsResponse struct {
+	Translation string          `json:"translation"`
+	Reference   Reference       `json:"reference"`
+	Threshold   float64         `json:"threshold"`
+	Scope       []int           `json:"scope"`
+	Parallels   []ParallelVerse `json:"parallels"`
+}
+
+// Default and lowest accepted Jaccard similarity for a parallel
+const (
+	defaultParallelThreshold = 0.5
+	minParallelThreshold     = 0.2
+)
+
+// Most parallels returned for one verse
+const maxParallels = 20
+
+// Books compared with each other; a verse outside these groups is compared
+// with its own testament
+var parallelScopes = [][]int{
+	// Gospels
+	{470, 480, 490, 500},
+	// Samuel, Kings and Chronicles with the Psalms, Isaiah and Jeremiah passages they share
+	{90, 100, 110, 120, 130, 140, 230, 290, 300},
+	// Ephesians and Colossians
+	{560, 580},
+	// 2 Peter and Jude
+	{680, 720},
+}
+
+// Books searched for parallels of a verse in book, or nil for its testament
+func parallelScope(book int) []int {
+	for _, scope := range parallelScopes {
+		for _, member := range scope {
+			if member == book {
+				return scope
+			}
+		}
+	}
+	return nil
+}
+
+// Distinct normalized words of raw verse text
+func verseTokens(text, language string) map[string]bool {
+	tokens := make(map[string]bool)
+	fields := strings.FieldsFunc(normalizeSearchText(text, language, true), func(r rune) bool {
+		return !isWordRune(r)
+	})
+	for _, field := range fields {
+		tokens[field] = true
+	}
+	return tokens
+}
+
+// Jaccard similarity of two word sets: shared words over all distinct words
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// Get verses whose wording closely matches a verse handler
+func parallelsHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	threshold := defaultParallelThreshold
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || threshold < minParallelThreshold || threshold > 1 {
+			respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid threshold, expected a number between %g and 1", minParallelThreshold), http.StatusBadRequest)
+			return
+		}
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := fetchVerses(r.Context(), db, translationName, []Reference{ref})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	if verses[0] == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+
+	dbMutex.RLock()
+	language := translationLanguages[translationName]
+	dbMutex.RUnlock()
+
+	var where string
+	var args []interface{}
+	scope := parallelScope(ref.Book)
+	if scope != nil {
+		placeholders := make([]string, len(scope))
+		for i, book := range scope {
+			placeholders[i] = "?"
+			args = append(args, book)
+		}
+		where = fmt.Sprintf("v.book_number IN (%s)", strings.Join(placeholders, ", "))
+	} else if ref.Book < 470 {
+		where = "v.book_number < 470"
+	} else {
+		where = "v.book_number >= 470"
+	}
+
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf(`
+		SELECT v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE %s
+		ORDER BY v.book_number, v.chapter, v.verse
+	`, where), args...)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to find parallels", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	target := verseTokens(verses[0].rawText, language)
+	parallels := make([]ParallelVerse, 0)
+	for rows.Next() {
+		var verse VerseResponse
+		if err := rows.Scan(&verse.BookNumber, &verse.Chapter, &verse.Verse, &verse.rawText, &verse.BookTitleShort, &verse.BookTitle); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to find parallels", http.StatusInternalServerError)
+			return
+		}
+		if verse.BookNumber == ref.Book && verse.Chapter == ref.Chapter && verse.Verse == ref.Verse {
+			continue
+		}
+		similarity := jaccard(target, verseTokens(verse.rawText, language))
+		if similarity < threshold {
+			continue
+		}
+		verse.Text = clearText(verse.rawText)
+		verse.Translation = translationName
+		parallels = append(parallels, ParallelVerse{VerseResponse: verse, Similarity: similarity})
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to find parallels", http.StatusInternalServerError)
+		return
+	}
+
+	// Most similar first; ties stay in canonical order
+	sort.SliceStable(parallels, func(i, j int) bool {
+		return parallels[i].Similarity > parallels[j].Similarity
+	})
+	if len(parallels) > maxParallels {
+		parallels = parallels[:maxParallels]
+	}
+
+	if scope == nil {
+		scope = make([]int, 0)
+	}
+	writeJSON(w, http.StatusOK, ParallelsResponse{
+		Translation: translationName,
+		Reference:   ref,
+		Threshold:   threshold,
+		Scope:       scope,
+		Parallels:   parallels,
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type PreviewChapter struct {
+	Chapter int            `json:"chapter"`
+	Verses  []ChapterVerse `json:"verses"`
+}
+
+type PreviewResponse struct {
+	Translation string           `json:"translation"`
+	BookNumber  int              `json:"book_number"`
+	ShortName   string           `json:"short_name"`
+	LongName    string           `json:"long_name"`
+	PerChapter  int              `json:"per_chapter"`
+	Chapters    []PreviewChapter `json:"chapters"`
+}
+
+// Upper bound on ?per_chapter for book previews
+const maxPreviewVerses = 10
+
+// Get the first verses of every chapter in a book handler
+func previewsHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	perChapter, err := parseIntParam(r.URL.Query().Get("per_chapter"), 1, 1, maxPreviewVerses)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid per_chapter: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	response := PreviewResponse{Translation: translationName, BookNumber: ref.Book, PerChapter: perChapter}
+	err = db.QueryRowContext(r.Context(), "SELECT short_name, long_name FROM books WHERE book_number = ?", ref.Book).Scan(&response.ShortName, &response.LongName)
+	if err == sql.ErrNoRows {
+		respondWithError(w, errCodeBookNotFound, fmt.Sprintf("Book %d not found", ref.Book), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve previews", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT chapter, verse, text FROM (
+			SELECT chapter, verse, text,
+				ROW_NUMBER() OVER (PARTITION BY chapter ORDER BY verse) AS rn
+			FROM verses
+			WHERE book_number = ?
+		)
+		WHERE rn <= ?
+		ORDER BY chapter, verse
+	`, ref.Book, perChapter)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve previews", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	response.Chapters = make([]PreviewChapter, 0)
+	for rows.Next() {
+		var chapter int
+		var verse ChapterVerse
+		if err := rows.Scan(&chapter, &verse.Verse, &verse.rawText); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve previews", http.StatusInternalServerError)
+			return
+		}
+		verse.Text = clearText(verse.rawText)
+
+		// Rows arrive grouped by chapter, so a new chapter number starts a new group
+		if n := len(response.Chapters); n == 0 || response.Chapters[n-1].Chapter != chapter {
+			response.Chapters = append(response.Chapters, PreviewChapter{Chapter: chapter})
+		}
+		last := &response.Chapters[len(response.Chapters)-1]
+		last.Verses = append(last.Verses, verse)
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve previews", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
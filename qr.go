@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Side length of QR code images in pixels
+const (
+	qrDefaultSize = 256
+	qrMaxSize     = 1024
+)
+
+// Public origin of the API used in permalinks (env PUBLIC_BASE_URL); when
+// empty it is taken from the request
+var publicBaseURL = ""
+
+// Origin of the API as seen by the client, without a trailing slash
+func requestBaseURL(r *http.Request) string {
+	if publicBaseURL != "" {
+		return strings.TrimRight(publicBaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); trustProxyHeaders && proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// Get a PNG QR code of a verse permalink handler
+func qrHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	size, err := parseIntParam(r.URL.Query().Get("size"), qrDefaultSize, 64, qrMaxSize)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid size: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := store.GetVerses(r.Context(), []Reference{ref})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	if verses[0] == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+
+	permalink := fmt.Sprintf("%s/get-verse/%s/%d/%d/%d/", requestBaseURL(r), translationName, ref.Book, ref.Chapter, ref.Verse)
+	png, err := qrcode.Encode(permalink, qrcode.Medium, size)
+	if err != nil {
+		respondWithError(w, errCodeInternal, "Failed to render QR code", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(png)
+}
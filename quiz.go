@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+)
+
+type QuizOption struct {
+	Reference Reference `json:"reference"`
+	Label     string    `json:"label"`
+}
+
+type QuizResponse struct {
+	Translation string       `json:"translation"`
+	Text        string       `json:"text"`
+	Options     []QuizOption `json:"options"`
+	Answer      int          `json:"answer"`
+}
+
+// Wrong answers offered with each quiz question
+const quizDistractors = 3
+
+// Get a random verse with shuffled reference options handler. One
+// distractor comes from the same book when it has other verses, so the
+// question is not answered by the book alone.
+func quizHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verse, err := store.RandomVerse(r.Context(), 0, nil)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to build quiz", http.StatusInternalServerError)
+		return
+	}
+	if verse == nil {
+		respondWithError(w, errCodeVerseNotFound, "No verse to choose from", http.StatusNotFound)
+		return
+	}
+
+	options := []*VerseResponse{verse}
+	used := []Reference{{Book: verse.BookNumber, Chapter: verse.Chapter, Verse: verse.Verse}}
+	for i := 0; i < quizDistractors; i++ {
+		book := 0
+		if i == 0 {
+			book = verse.BookNumber
+		}
+		distractor, err := store.RandomVerse(r.Context(), book, used)
+		if err == nil && distractor == nil && book != 0 {
+			distractor, err = store.RandomVerse(r.Context(), 0, used)
+		}
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to build quiz", http.StatusInternalServerError)
+			return
+		}
+		if distractor == nil {
+			break
+		}
+		options = append(options, distractor)
+		used = append(used, Reference{Book: distractor.BookNumber, Chapter: distractor.Chapter, Verse: distractor.Verse})
+	}
+
+	response := QuizResponse{
+		Translation: translationName,
+		Text:        verse.Text,
+		Options:     make([]QuizOption, len(options)),
+	}
+	for i, j := range rand.Perm(len(options)) {
+		option := options[j]
+		response.Options[i] = QuizOption{
+			Reference: Reference{Book: option.BookNumber, Chapter: option.Chapter, Verse: option.Verse},
+			Label:     fmt.Sprintf("%s %d:%d", option.BookTitle, option.Chapter, option.Verse),
+		}
+		if j == 0 {
+			response.Answer = i
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Upper bound on tracked clients; the least recently seen are dropped beyond it
+const maxRateLimitClients = 10000
+
+// Token bucket per client IP
+type tokenBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+	// Position in rateLimiter.order
+	element *list.Element
+}
+
+// Rate limiter refilling burst tokens over one minute
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[string]*tokenBucket
+	// Buckets from most to least recently used
+	order *list.List
+}
+
+// Snapshot of a client's bucket after a request
+type rateLimitState struct {
+	allowed   bool
+	limit     int
+	remaining int
+	reset     time.Duration // until the bucket is full again
+	retry     time.Duration // until the next token is available
+}
+
+// Active limiter (nil when RATE_LIMIT_RPM is unset or 0)
+var limiter *rateLimiter
+
+// Stricter limiter for expensive endpoints such as regex search (REGEX_RATE_LIMIT_RPM)
+var expensiveLimiter = newRateLimiter(10, 10)
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		order:   list.New(),
+	}
+}
+
+// Take a token for key and report the resulting bucket state
+func (l *rateLimiter) take(key string, now time.Time) rateLimitState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if ok {
+		l.order.MoveToFront(bucket.element)
+	} else {
+		l.prune(now)
+		bucket = &tokenBucket{key: key, tokens: l.burst, last: now}
+		bucket.element = l.order.PushFront(bucket)
+		l.buckets[key] = bucket
+	}
+
+	// Refill for the time elapsed since the last request
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	state := rateLimitState{limit: int(l.burst)}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		state.allowed = true
+	} else {
+		state.retry = time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	}
+	state.remaining = int(bucket.tokens)
+	state.reset = time.Duration((l.burst - bucket.tokens) / l.rate * float64(time.Second))
+	return state
+}
+
+// Make room for a new bucket. Refilled buckets carry no state worth keeping
+// and are dropped from the least recently used end; when the limit is still
+// reached the least recently used bucket goes too, even if it is not full.
+func (l *rateLimiter) prune(now time.Time) {
+	for element := l.order.Back(); element != nil; element = l.order.Back() {
+		bucket := element.Value.(*tokenBucket)
+		refilled := bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate >= l.burst
+		if !refilled && len(l.buckets) < maxRateLimitClients {
+			return
+		}
+		l.order.Remove(element)
+		delete(l.buckets, bucket.key)
+	}
+}
+
+// Rate limiting middleware
+func rateLimitMiddleware(l *rateLimiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := l.take(clientIP(r), time.Now())
+
+		w.Header().Set(headerRateLimitLimit, strconv.Itoa(state.limit))
+		w.Header().Set(headerRateLimitRemaining, strconv.Itoa(state.remaining))
+		w.Header().Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(state.reset)))
+
+		if !state.allowed {
+			w.Header().Set(headerRetryAfter, strconv.Itoa(ceilSeconds(state.retry)))
+			respondWithError(w, errCodeRateLimited, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
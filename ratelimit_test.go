@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterStaysCapped(t *testing.T) {
+	l := newRateLimiter(60, 5)
+	now := time.Now()
+
+	// Every client is active, so nothing has refilled and pruning frees nothing
+	for i := 0; i < maxRateLimitClients+100; i++ {
+		l.take(fmt.Sprintf("10.0.%d.%d", i/256, i%256), now)
+	}
+	if len(l.buckets) != maxRateLimitClients || l.order.Len() != maxRateLimitClients {
+		t.Fatalf("tracking %d buckets (%d ordered), want %d", len(l.buckets), l.order.Len(), maxRateLimitClients)
+	}
+	if _, ok := l.buckets["10.0.0.0"]; ok {
+		t.Error("least recently used client was kept")
+	}
+	if _, ok := l.buckets[fmt.Sprintf("10.0.%d.%d", (maxRateLimitClients+99)/256, (maxRateLimitClients+99)%256)]; !ok {
+		t.Error("newest client is not tracked")
+	}
+}
+
+func TestRateLimiterPrunesRefilledBuckets(t *testing.T) {
+	l := newRateLimiter(60, 5)
+	start := time.Now()
+	for i := 0; i < maxRateLimitClients; i++ {
+		l.take(fmt.Sprintf("client-%d", i), start)
+	}
+
+	// A minute later every bucket has refilled and can go
+	l.take("late", start.Add(time.Minute))
+	if len(l.buckets) != 1 {
+		t.Fatalf("tracking %d buckets, want only the new one", len(l.buckets))
+	}
+}
+
+func TestRateLimiterRecentUseKeepsBucket(t *testing.T) {
+	l := newRateLimiter(60, 5)
+	now := time.Now()
+	for i := 0; i < maxRateLimitClients; i++ {
+		l.take(fmt.Sprintf("client-%d", i), now)
+	}
+
+	// Using the oldest client moves it to the front, so the next one is evicted
+	l.take("client-0", now)
+	l.take("new", now)
+	if _, ok := l.buckets["client-0"]; !ok {
+		t.Error("recently used client was evicted")
+	}
+	if _, ok := l.buckets["client-1"]; ok {
+		t.Error("least recently used client was kept")
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	l := newRateLimiter(60, 2)
+	now := time.Now()
+
+	for i, want := range []bool{true, true, false} {
+		if state := l.take("ip", now); state.allowed != want {
+			t.Fatalf("request %d allowed = %v, want %v", i+1, state.allowed, want)
+		}
+	}
+	if state := l.take("ip", now.Add(time.Second)); !state.allowed || state.remaining != 0 {
+		t.Fatalf("after one second got %+v, want one refilled token used", state)
+	}
+}
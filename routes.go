@@ -0,0 +1,148 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// A route declares its method, its path pattern (http.ServeMux wildcard
+// syntax), the handler serving it and the query parameters it accepts.
+type route struct {
+	method  string
+	pattern string
+	handler http.HandlerFunc
+	params  []string
+}
+
+// Query parameters of the verse text options shared by several routes
+var textParams = []string{"include", "transforms", "strongs"}
+
+// Join parameter lists for the route table
+func params(groups ...[]string) []string {
+	var all []string
+	for _, group := range groups {
+		all = append(all, group...)
+	}
+	return all
+}
+
+// Route table
+func routes() []route {
+	return []route{
+		{http.MethodGet, "/get-random-verse/{translation}", withRandomCacheControl(getRandomVerseHandler), params(textParams, []string{"weights", "exclude"})},
+		{http.MethodGet, "/seeded-verse/{translation}", seededVerseHandler, params(textParams, []string{"seed"})},
+		{http.MethodGet, "/verse-of-the-day/{translation}", verseOfTheDayHandler, params(textParams, []string{"date"})},
+		{http.MethodGet, "/quiz/{translation}", withRandomCacheControl(quizHandler), nil},
+		{http.MethodGet, "/mood-verses/{translation}", withRandomCacheControl(moodVersesHandler), []string{"mood", "count"}},
+		{http.MethodGet, "/sampler/{translation}", withRandomCacheControl(samplerHandler), params(textParams, []string{"books"})},
+		{http.MethodGet, "/get-verse/{translation}/{book}/{chapter}/{verse}", getVerseHandler, params(textParams, []string{"format", "fallback", "canonical", "footnotes"})},
+		{http.MethodGet, "/parallels/{translation}/{book}/{chapter}/{verse}", withExpensiveLimit(parallelsHandler), []string{"threshold"}},
+		{http.MethodGet, "/sentences/{translation}/{book}/{chapter}/{verse}", sentencesHandler, []string{"clauses"}},
+		{http.MethodGet, "/caption/{translation}/{book}/{chapter}/{verse}", captionHandler, []string{"max_chars", "max_lines"}},
+		{http.MethodGet, "/get-random-chapter/{translation}", withRandomCacheControl(getRandomChapterHandler), nil},
+		{http.MethodGet, "/get-chapter/{translation}/{book}/{chapter}", getChapterHandler, []string{"inline_numbers", "paragraphs", "transforms", "strongs", "format", "order", "footnotes"}},
+		{http.MethodGet, "/passage/{translation}/{book}", passageHandler, []string{"from", "to", "order", "format"}},
+		{http.MethodGet, "/shuffle-plan/{translation}", shufflePlanHandler, []string{"seed", "day", "per_day"}},
+		{http.MethodGet, "/get-chapters/{translation}/{book}", getChaptersHandler, []string{"from", "to", "order"}},
+		{http.MethodGet, "/plain/{translation}/{book}/{chapter}", plainChapterHandler, []string{"width"}},
+		{http.MethodGet, "/count/{translation}/{book}", countHandler, []string{"from", "to"}},
+		{http.MethodGet, "/count/{translation}/{book}/{chapter}", countHandler, []string{"from", "to"}},
+		{http.MethodGet, "/random-compare", withRandomCacheControl(randomCompareHandler), []string{"translations"}},
+		{http.MethodGet, "/compare/{book}/{chapter}/{verse}", compareHandler, []string{"translations", "canonical"}},
+		{http.MethodGet, "/availability/{book}/{chapter}/{verse}", availabilityHandler, []string{"translations"}},
+		{http.MethodGet, "/length-compare/{book}/{chapter}/{verse}", lengthCompareHandler, []string{"translations", "canonical"}},
+		{http.MethodGet, "/parallel/{book}/{chapter}", parallelHandler, []string{"translations"}},
+		{http.MethodGet, "/versification-diff", versificationDiffHandler, []string{"a", "b", "book"}},
+		{http.MethodGet, "/random-by-regex/{translation}", withExpensiveLimit(withRandomCacheControl(randomByRegexHandler)), []string{"pattern"}},
+		{http.MethodGet, "/concordance/{translation}", concordanceHandler, []string{"word", "n"}},
+		{http.MethodGet, "/search/{translation}", searchHandler, []string{"q", "limit", "offset", "cursor", "ignore_accents", "include", "stream", "format"}},
+		{http.MethodPost, "/batch/{translation}", batchVersesHandler, []string{"include"}},
+		{http.MethodPost, "/rpc", rpcHandler, nil},
+		{http.MethodGet, "/verses/{translation}", getVersesHandler, []string{"refs", "include"}},
+		{http.MethodGet, "/lectionary", lectionaryHandler, []string{"date", "translation", "lectionary"}},
+		{http.MethodGet, "/oembed", oembedHandler, []string{"url", "format", "maxwidth", "maxheight"}},
+		{http.MethodGet, "/translations", translationsHandler, nil},
+		{http.MethodGet, "/schema/{translation}", schemaHandler, nil},
+		{http.MethodGet, "/books/{translation}", booksHandler, []string{"order", "include_apocrypha"}},
+		{http.MethodGet, "/book-numbers/{translation}", bookNumbersHandler, nil},
+		{http.MethodGet, "/outline/{translation}", outlineHandler, []string{"verses", "include_apocrypha"}},
+		{http.MethodGet, "/toc/{translation}", tocHandler, nil},
+		{http.MethodGet, "/stats/{translation}/books", bookStatsHandler, nil},
+		{http.MethodGet, "/stats/{translation}/verse-lengths", verseLengthsHandler, nil},
+		{http.MethodGet, "/previews/{translation}/{book}", previewsHandler, []string{"per_chapter"}},
+		{http.MethodGet, "/chapter-nav/{translation}/{book}/{chapter}", chapterNavHandler, nil},
+		{http.MethodGet, "/next-book/{translation}/{book}", adjacentBookHandler(true), []string{"include_chapter", "include_apocrypha"}},
+		{http.MethodGet, "/prev-book/{translation}/{book}", adjacentBookHandler(false), []string{"include_chapter", "include_apocrypha"}},
+		{http.MethodGet, "/audio/{translation}/{book}/{chapter}", audioHandler, nil},
+		{http.MethodGet, "/variants/{translation}/{book}/{chapter}/{verse}", variantsHandler, nil},
+		{http.MethodGet, "/qr/{translation}/{book}/{chapter}/{verse}", qrHandler, []string{"size"}},
+		{http.MethodGet, "/cross-refs/{translation}/{book}/{chapter}/{verse}", getCrossReferencesHandler, []string{"include_text"}},
+		{http.MethodGet, "/sitemap.xml", sitemapHandler, nil},
+		{http.MethodGet, "/sitemaps/{file}", translationSitemapHandler, nil},
+		{http.MethodGet, "/health", healthHandler, nil},
+		{http.MethodGet, "/readyz", readyzHandler, nil},
+		{http.MethodGet, "/metrics", metricsHandler, nil},
+		{http.MethodPost, "/admin/reload", adminMiddleware(adminReloadHandler), nil},
+		{http.MethodPost, "/admin/maintenance", adminMiddleware(adminMaintenanceHandler), nil},
+		{http.MethodGet, "/admin/stats", adminMiddleware(adminStatsHandler), []string{"limit"}},
+	}
+}
+
+// Methods used in the route table, probed to tell 405 from 404
+var routeMethods = []string{http.MethodGet, http.MethodPost}
+
+// Build the HTTP handler serving every route
+func newRouter() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range routes() {
+		handler := rt.handler
+		if !maintenanceExempt(rt.pattern) {
+			handler = maintenanceMiddleware(handler)
+		}
+		// Accept the path with and without a trailing slash
+		h := withMiddleware(strictParamsMiddleware(rt.params, handler))
+		mux.HandleFunc(rt.method+" "+rt.pattern, h)
+		mux.HandleFunc(rt.method+" "+rt.pattern+"/{$}", h)
+	}
+
+	// Ping skips the middleware chain so monitors add no logging overhead
+	mux.HandleFunc(http.MethodGet+" /ping", pingHandler)
+
+	// Any other path gets a JSON 404 (or 405 when only the method is wrong)
+	mux.HandleFunc("/", withMiddleware(notFoundHandler(mux)))
+
+	inner := mux.ServeHTTP
+	if requestTimeout > 0 {
+		inner = timeoutMiddleware(mux, requestTimeout)
+	}
+
+	handler := compressionMiddleware(corsMiddleware(inner))
+	if securityHeadersEnabled {
+		handler = securityHeadersMiddleware(handler)
+	}
+	return handler
+}
+
+// Catch-all handler so unknown routes get the same JSON error shape
+func notFoundHandler(mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range routeMethods {
+			if method == r.Method {
+				continue
+			}
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			if _, pattern := mux.Handler(probe); pattern != "" && pattern != "/" {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			respondWithError(w, errCodeMethodNotAllowed, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		respondWithError(w, errCodeNotFound, notFoundMessage, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type RPCResult struct {
+	Op     string          `json:"op"`
+	Status int             `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ErrorResponse  `json:"error,omitempty"`
+}
+
+// Most operations one /rpc request may carry
+const maxRPCOps = 10
+
+// Operations available through /rpc, each served by the route with this pattern
+var rpcOps = map[string]string{
+	"verse":            "/get-verse/{translation}/{book}/{chapter}/{verse}",
+	"chapter":          "/get-chapter/{translation}/{book}/{chapter}",
+	"random":           "/get-random-verse/{translation}",
+	"verse_of_the_day": "/verse-of-the-day/{translation}",
+	"search":           "/search/{translation}",
+	"books":            "/books/{translation}",
+	"toc":              "/toc/{translation}",
+}
+
+var rpcWildcardRegex = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// Response writer capturing one operation's result
+type rpcResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *rpcResponseWriter) Header() http.Header { return c.header }
+
+func (c *rpcResponseWriter) WriteHeader(statusCode int) {
+	if c.status == 0 {
+		c.status = statusCode
+	}
+}
+
+func (c *rpcResponseWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	return c.body.Write(p)
+}
+
+// Format a JSON scalar as a path or query value
+func rpcValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// An error result for an operation that could not be run
+func rpcError(op, code, message string, status int) RPCResult {
+	return RPCResult{Op: op, Status: status, Error: &ErrorResponse{Error: message, Code: code}}
+}
+
+// Run one operation through its route's handler: wildcards of the pattern are
+// taken from the fields of the same name and the other fields become query
+// parameters, checked against the route's parameter list
+func runRPCOp(r *http.Request, table map[string]route, fields map[string]interface{}) RPCResult {
+	op, _ := fields["op"].(string)
+	pattern, known := rpcOps[op]
+	if !known {
+		names := make([]string, 0, len(rpcOps))
+		for name := range rpcOps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return rpcError(op, errCodeInvalidParameter, fmt.Sprintf("Unknown op '%s', expected one of %s", op, strings.Join(names, ", ")), http.StatusBadRequest)
+	}
+	rt := table[pattern]
+
+	values := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if name == "op" {
+			continue
+		}
+		text, ok := rpcValue(value)
+		if !ok {
+			return rpcError(op, errCodeInvalidParameter, fmt.Sprintf("Field '%s' must be a string, number or boolean", name), http.StatusBadRequest)
+		}
+		values[name] = text
+	}
+
+	pathValues := make(map[string]string)
+	for _, match := range rpcWildcardRegex.FindAllStringSubmatch(pattern, -1) {
+		value, ok := values[match[1]]
+		if !ok {
+			return rpcError(op, errCodeInvalidParameter, fmt.Sprintf("Field '%s' is required for op '%s'", match[1], op), http.StatusBadRequest)
+		}
+		pathValues[match[1]] = value
+		delete(values, match[1])
+	}
+	path := rpcWildcardRegex.ReplaceAllStringFunc(pattern, func(wildcard string) string {
+		return url.PathEscape(pathValues[wildcard[1:len(wildcard)-1]])
+	})
+
+	query := url.Values{}
+	for name, value := range values {
+		if !containsString(rt.params, name) {
+			return rpcError(op, errCodeInvalidParameter, fmt.Sprintf("Unknown field '%s' for op '%s'", name, op), http.StatusBadRequest)
+		}
+		query.Set(name, value)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, path+"?"+query.Encode(), nil)
+	if err != nil {
+		return rpcError(op, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+	}
+	for name, value := range pathValues {
+		req.SetPathValue(name, value)
+	}
+
+	captured := &rpcResponseWriter{header: make(http.Header)}
+	rt.handler(captured, req)
+	if captured.status == 0 {
+		captured.status = http.StatusOK
+	}
+
+	if !strings.HasPrefix(captured.header.Get("Content-Type"), "application/json") || !json.Valid(captured.body.Bytes()) {
+		return rpcError(op, errCodeInvalidParameter, "Operation did not produce JSON; output formats are not supported over /rpc", http.StatusBadRequest)
+	}
+	if captured.status >= 400 {
+		var failure ErrorResponse
+		json.Unmarshal(captured.body.Bytes(), &failure)
+		return RPCResult{Op: op, Status: captured.status, Error: &failure}
+	}
+	return RPCResult{Op: op, Status: captured.status, Result: json.RawMessage(captured.body.Bytes())}
+}
+
+// Run several read operations in one round trip handler; results come back in
+// request order, each with its own status
+func rpcHandler(w http.ResponseWriter, r *http.Request) {
+	var ops []map[string]interface{}
+	if !decodeJSONBody(w, r, &ops, "Request body must be a JSON array of operation objects") {
+		return
+	}
+	if len(ops) == 0 {
+		respondWithError(w, errCodeInvalidBody, "Request body must contain at least one operation", http.StatusBadRequest)
+		return
+	}
+	if len(ops) > maxRPCOps {
+		respondWithError(w, errCodeInvalidBody, fmt.Sprintf("Batch size exceeds the maximum of %d operations", maxRPCOps), http.StatusBadRequest)
+		return
+	}
+
+	table := make(map[string]route)
+	for _, rt := range routes() {
+		table[rt.pattern] = rt
+	}
+
+	results := make([]RPCResult, len(ops))
+	for i, fields := range ops {
+		results[i] = runRPCOp(r, table, fields)
+	}
+	writeJSON(w, http.StatusOK, results)
+}
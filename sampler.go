@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type SamplerResponse struct {
+	Translation string          `json:"translation"`
+	Verses      []VerseResponse `json:"verses"`
+	Warnings    []string        `json:"warnings"`
+}
+
+// Maximum number of books in one sampler request
+const maxSamplerBooks = 66
+
+// Get one random verse from each requested book handler; books without
+// verses are skipped and reported in warnings
+func samplerHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	value := r.URL.Query().Get("books")
+	if value == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'books' is required", http.StatusBadRequest)
+		return
+	}
+	segments := strings.Split(value, ",")
+	if len(segments) > maxSamplerBooks {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("At most %d books can be sampled", maxSamplerBooks), http.StatusBadRequest)
+		return
+	}
+	books := make([]int, 0, len(segments))
+	for _, segment := range segments {
+		book, err := parseBook(translationName, strings.TrimSpace(segment))
+		if err != nil {
+			respondWithReferenceError(w, err)
+			return
+		}
+		books = append(books, book)
+	}
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	transforms, err := parseTransforms(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strongs, err := parseStrongs(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	response := SamplerResponse{
+		Translation: translationName,
+		Verses:      make([]VerseResponse, 0, len(books)),
+		Warnings:    make([]string, 0),
+	}
+	for _, book := range books {
+		verse, err := store.RandomVerse(r.Context(), book, nil)
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+			return
+		}
+		if verse == nil {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("Book %d not found", book))
+			continue
+		}
+		if transforms != nil || strongs != strongsStrip {
+			verse.Text = renderText(verse.rawText, verse.BookNumber, transforms, strongs)
+		}
+		if err := applyIncludes(r.Context(), store, verse, includes); err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+			return
+		}
+		response.Verses = append(response.Verses, *verse)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
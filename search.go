@@ -0,0 +1,437 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/text/unicode/norm"
+)
+
+type SearchResponse struct {
+	Translation string          `json:"translation"`
+	Query       string          `json:"query"`
+	Offset      int             `json:"offset"`
+	Limit       int             `json:"limit"`
+	HasMore     bool            `json:"has_more"`
+	NextCursor  string          `json:"next_cursor,omitempty"`
+	Results     []VerseResponse `json:"results"`
+}
+
+// SQLite driver with the Go text normalization function registered
+const sqliteDriverName = "sqlite3_bible"
+
+// Search paging limits
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	minSearchQueryLen  = 2
+)
+
+// Version tag of cursor tokens, so the format can change without misreading old ones
+const cursorVersion = "v1"
+
+// Encode the last returned verse as an opaque search cursor
+func encodeCursor(ref Reference) string {
+	raw := fmt.Sprintf("%s:%d:%d:%d", cursorVersion, ref.Book, ref.Chapter, ref.Verse)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode a search cursor back into the verse the next page starts after
+func decodeCursor(token string) (Reference, error) {
+	var ref Reference
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ref, fmt.Errorf("Invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[0] != cursorVersion {
+		return ref, fmt.Errorf("Invalid cursor")
+	}
+	values := []*int{&ref.Book, &ref.Chapter, &ref.Verse}
+	for i, part := range parts[1:] {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 {
+			return ref, fmt.Errorf("Invalid cursor")
+		}
+		*values[i] = n
+	}
+	return ref, nil
+}
+
+// Regex search limits
+const (
+	maxRegexPatternLen  = 200
+	maxRegexProgramSize = 2000
+	maxRegexCandidates  = 10000
+)
+
+// Matches every tag, including the ones clearText keeps for display
+var searchMarkupRegex = regexp.MustCompile(`<S>\d+</S>|<[^<>]*>`)
+
+// Per-language normalization rules applied before comparing text
+type languageRules struct {
+	// Letters folded to a base form in addition to case folding
+	replacer *strings.Replacer
+	// Letters written with a diacritic that are letters of their own, kept
+	// when accents are ignored
+	letters map[rune]bool
+}
+
+var languageNormalization = map[string]languageRules{
+	"ru": {replacer: strings.NewReplacer("ё", "е"), letters: map[rune]bool{'й': true}},
+}
+
+func init() {
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if pragma := mmapPragma(conn.GetFilename("main")); pragma != "" {
+				if _, err := conn.Exec(pragma, nil); err != nil {
+					return err
+				}
+			}
+			return conn.RegisterFunc("normalize_text", normalizeSearchText, true)
+		},
+	})
+}
+
+// Normalize text for comparison: strip markup, compose to NFC, fold case
+// (Unicode aware), optionally strip diacritics and apply language-specific
+// letter folding.
+func normalizeSearchText(text string, language string, ignoreAccents bool) string {
+	text = strings.ToLower(norm.NFC.String(plainText(text)))
+
+	rules := languageNormalization[language]
+	if ignoreAccents {
+		text = stripAccents(text, rules.letters)
+	}
+
+	if rules.replacer != nil {
+		text = rules.replacer.Replace(text)
+	}
+	return text
+}
+
+// Remove combining marks, except from the given letters
+func stripAccents(text string, letters map[rune]bool) string {
+	var b strings.Builder
+	for _, r := range norm.NFC.String(text) {
+		if letters[r] {
+			b.WriteRune(r)
+			continue
+		}
+		for _, d := range norm.NFD.String(string(r)) {
+			if !unicode.Is(unicode.Mn, d) {
+				b.WriteRune(d)
+			}
+		}
+	}
+	return norm.NFC.String(b.String())
+}
+
+// Escape LIKE wildcards so the query matches literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// Random verse matching a regular expression handler.
+// Go's RE2 engine runs in linear time, so the guards below only bound
+// pattern size and the number of verses scanned.
+func randomByRegexHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	re, err := compileUserRegex(r.URL.Query().Get("pattern"))
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	// Scan a random, bounded candidate set and return the first match
+	rows, err := db.Query(`
+		SELECT v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		ORDER BY RANDOM()
+		LIMIT ?
+	`, maxRegexCandidates)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var verse VerseResponse
+		var rawText string
+		if err := rows.Scan(&verse.BookNumber, &verse.Chapter, &verse.Verse, &rawText, &verse.BookTitleShort, &verse.BookTitle); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+			return
+		}
+		verse.Text = clearText(rawText)
+		if !re.MatchString(plainText(verse.Text)) {
+			continue
+		}
+		verse.Translation = translationName
+		writeJSON(w, http.StatusOK, verse)
+		return
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithError(w, errCodeVerseNotFound, "No verse matches the pattern", http.StatusNotFound)
+}
+
+// Compile a client-supplied pattern, rejecting oversized or overly complex ones
+func compileUserRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("Query parameter 'pattern' is required")
+	}
+	if len(pattern) > maxRegexPatternLen {
+		return nil, fmt.Errorf("Pattern exceeds %d characters", maxRegexPatternLen)
+	}
+
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid pattern: %v", err)
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("Invalid pattern: %v", err)
+	}
+	if len(prog.Inst) > maxRegexProgramSize {
+		return nil, fmt.Errorf("Pattern is too complex")
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// Search verses handler
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	params := r.URL.Query()
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := strings.TrimSpace(params.Get("q"))
+	if len([]rune(query)) < minSearchQueryLen {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Query parameter 'q' must be at least %d characters", minSearchQueryLen), http.StatusBadRequest)
+		return
+	}
+
+	stream := false
+	if value := params.Get("stream"); value != "" {
+		stream, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid stream value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	format := params.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unsupported format '%s'", format), http.StatusBadRequest)
+		return
+	}
+	if format == "csv" && stream {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'format=csv' cannot be combined with stream", http.StatusBadRequest)
+		return
+	}
+	// These run a query per verse, which would need a second connection
+	// while the stream still holds its rows
+	if stream && (includes["index"] || includes["translit"]) {
+		respondWithError(w, errCodeInvalidParameter, "Includes 'index' and 'translit' cannot be combined with stream", http.StatusBadRequest)
+		return
+	}
+
+	// Streams are not paged: every match is sent unless a limit is given
+	limit, err := parseIntParam(params.Get("limit"), defaultSearchLimit, 1, maxSearchLimit)
+	if stream {
+		limit, err = parseIntParam(params.Get("limit"), -1, 1, -1)
+	}
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid limit: %v", err), http.StatusBadRequest)
+		return
+	}
+	offset, err := parseIntParam(params.Get("offset"), 0, 0, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid offset: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// A cursor resumes after the last verse of the previous page, so results
+	// do not shift as deep pages are fetched
+	var after *Reference
+	if token := params.Get("cursor"); token != "" {
+		if params.Get("offset") != "" {
+			respondWithError(w, errCodeInvalidParameter, "Parameters 'cursor' and 'offset' cannot be combined", http.StatusBadRequest)
+			return
+		}
+		ref, err := decodeCursor(token)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+			return
+		}
+		after = &ref
+	}
+
+	ignoreAccents := true
+	if value := params.Get("ignore_accents"); value != "" {
+		ignoreAccents, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid ignore_accents value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	dbMutex.RLock()
+	language := translationLanguages[translationName]
+	dbMutex.RUnlock()
+
+	if stream {
+		streamSearch(w, r, store, includes, searchQuery{
+			Text:          query,
+			Language:      language,
+			IgnoreAccents: ignoreAccents,
+			Limit:         limit,
+			Offset:        offset,
+			After:         after,
+		})
+		return
+	}
+
+	// Fetch one extra row to know whether another page exists
+	results, err := store.Search(r.Context(), searchQuery{
+		Text:          query,
+		Language:      language,
+		IgnoreAccents: ignoreAccents,
+		Limit:         limit + 1,
+		Offset:        offset,
+		After:         after,
+	})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to search verses", http.StatusInternalServerError)
+		return
+	}
+
+	hasMore := len(results) > limit
+	nextCursor := ""
+	if hasMore {
+		results = results[:limit]
+		last := results[limit-1]
+		nextCursor = encodeCursor(Reference{Book: last.BookNumber, Chapter: last.Chapter, Verse: last.Verse})
+	}
+
+	// CSV carries the page's rows only; the next cursor is not included
+	if format == "csv" {
+		rows := make([][]string, len(results))
+		for i, verse := range results {
+			rows[i] = csvRow(verse.BookNumber, verse.Chapter, verse.Verse, verse.Text)
+		}
+		respondWithCSV(w, fmt.Sprintf("%s-search.csv", translationName), rows)
+		return
+	}
+
+	for i := range results {
+		if err := applyIncludes(r.Context(), store, &results[i], includes); err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to search verses", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, SearchResponse{
+		Translation: translationName,
+		Query:       query,
+		Offset:      offset,
+		Limit:       limit,
+		HasMore:     hasMore,
+		NextCursor:  nextCursor,
+		Results:     results,
+	})
+}
+
+// Write search matches as NDJSON, one verse per line, flushing each as it is
+// read so memory stays flat and clients see results early. Errors after the
+// first line can only end the stream, so they are logged.
+func streamSearch(w http.ResponseWriter, r *http.Request, store VerseStore, includes map[string]bool, query searchQuery) {
+	translationName := r.PathValue("translation")
+	flusher := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	started := false
+	err := store.SearchEach(r.Context(), query, func(verse VerseResponse) error {
+		if err := applyIncludes(r.Context(), store, &verse, includes); err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := encoder.Encode(verse); err != nil {
+			return err
+		}
+		// Unsupported behind the buffering debug envelope
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		logQueryError(translationName, err)
+		if !started {
+			respondWithError(w, errCodeInternal, "Failed to search verses", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !started {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Parse an optional integer query parameter within [min, max] (max < 0 means unbounded)
+func parseIntParam(value string, fallback, min, max int) (int, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", value)
+	}
+	if n < min || (max >= 0 && n > max) {
+		if max >= 0 {
+			return 0, fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return 0, fmt.Errorf("must be at least %d", min)
+	}
+	return n, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeSearchText(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		language      string
+		ignoreAccents bool
+		want          string
+	}{
+		{"lowercases Cyrillic", "ВНАЧАЛЕ СОТВОРИЛ Бог", "ru", false, "вначале сотворил бог"},
+		{"folds yo to ye in Russian", "Её ёлка", "ru", false, "ее елка"},
+		{"folds capital yo", "ЁЖ", "ru", false, "еж"},
+		{"keeps yo outside Russian", "ёж", "en", false, "ёж"},
+		{"keeps stress marks by default", "Бо́г", "ru", false, "бо́г"},
+		{"strips stress marks", "Бо́г", "ru", true, "бог"},
+		{"strips composed accents", "Éloï", "en", true, "eloi"},
+		{"keeps short i when stripping accents", "Мой бо́й", "ru", true, "мой бой"},
+		{"removes markup", "и сказал <S>559</S> <i>Бог</i>", "ru", false, "и сказал бог"},
+		{"composes decomposed letters", "И\u0306ОСИФ", "ru", false, "\u0439осиф"},
+		{"composes decomposed accents", "Cafe\u0301", "en", false, "caf\u00e9"},
+		{"collapses whitespace", "  свет \t во  тьме ", "ru", false, "свет во тьме"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSearchText(tt.text, tt.language, tt.ignoreAccents); got != tt.want {
+				t.Errorf("normalizeSearchText(%q, %q, %v) = %q, want %q", tt.text, tt.language, tt.ignoreAccents, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeSearchTextQueryMatchesVerse(t *testing.T) {
+	// The query and the stored text go through the same normalization, so
+	// spelling differences the user does not see still match
+	query := normalizeSearchText("Ёлка", "ru", true)
+	verse := normalizeSearchText("под зелёной е́лкой", "ru", true)
+	if query != "елка" || verse != "под зеленой елкой" {
+		t.Fatalf("got query %q and verse %q", query, verse)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, ref := range []Reference{{Book: 10, Chapter: 1, Verse: 1}, {Book: 730, Chapter: 22, Verse: 21}, {Book: 230, Chapter: 119, Verse: 176}} {
+		token := encodeCursor(ref)
+		got, err := decodeCursor(token)
+		if err != nil || got != ref {
+			t.Errorf("decodeCursor(encodeCursor(%+v)) = %+v, %v", ref, got, err)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsBadTokens(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"not base64", "!!not-a-cursor!!"},
+		{"other version", encode("v2:10:1:1")},
+		{"missing part", encode("v1:10:1")},
+		{"extra part", encode("v1:10:1:1:1")},
+		{"not a number", encode("v1:10:one:1")},
+		{"zero verse", encode("v1:10:1:0")},
+		{"negative book", encode("v1:-10:1:1")},
+		{"tampered", encodeCursor(Reference{Book: 10, Chapter: 1, Verse: 1})[:6] + "xx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ref, err := decodeCursor(tt.token); err == nil {
+				t.Errorf("decodeCursor(%q) = %+v, want an error", tt.token, ref)
+			}
+		})
+	}
+}
+
+func TestSearchCursorPaging(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	var seen []int
+	target := "/search/TEST?q=and&limit=1"
+	for page := 0; page < len(testGenesis)+1; page++ {
+		rec := serve(http.MethodGet, target, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d (body %s)", target, rec.Code, rec.Body)
+		}
+		var response SearchResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		for _, verse := range response.Results {
+			seen = append(seen, verse.Verse)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		target = "/search/TEST?q=and&limit=1&cursor=" + response.NextCursor
+	}
+	if len(seen) != 3 || seen[0] != 1 || seen[1] != 2 || seen[2] != 3 {
+		t.Fatalf("paged through verses %v, want [1 2 3]", seen)
+	}
+
+	assertError(t, serve(http.MethodGet, "/search/TEST?q=and&cursor=garbage", ""), http.StatusBadRequest, errCodeInvalidParameter)
+}
+
+func TestSearchMatchesAcrossNormalizationForms(t *testing.T) {
+	// The module stores the decomposed form, the query is typed composed
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "ru", []testVerse{
+		{10, 1, 1, "И сказал Бог: да будет свет. И\u0306осиф"},
+		{10, 1, 2, "и стал свет."},
+	})})
+
+	for _, target := range []string{"/search/TEST?q=%D0%B9%D0%BE%D1%81%D0%B8%D1%84&ignore_accents=false", "/search/TEST?q=%D0%B8%CC%86%D0%BE%D1%81%D0%B8%D1%84&ignore_accents=false"} {
+		rec := serve(http.MethodGet, target, "")
+		var response SearchResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("GET %s: %v (%s)", target, err, rec.Body)
+		}
+		if len(response.Results) != 1 || response.Results[0].Verse != 1 {
+			t.Errorf("GET %s returned %+v, want verse 1", target, response.Results)
+		}
+	}
+}
+
+func TestSearchStream(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	rec := serve(http.MethodGet, "/search/TEST?q=and&stream=true&include=rowid", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status = %d, Content-Type = %q (body %s)", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	decoder := json.NewDecoder(rec.Body)
+	var seen []int
+	for decoder.More() {
+		var verse VerseResponse
+		if err := decoder.Decode(&verse); err != nil {
+			t.Fatal(err)
+		}
+		if verse.RowID == nil {
+			t.Errorf("verse %d has no rowid", verse.Verse)
+		}
+		seen = append(seen, verse.Verse)
+	}
+	if len(seen) != 3 {
+		t.Fatalf("streamed verses %v, want all 3", seen)
+	}
+
+	// Per-verse lookups would nest a query inside the open stream
+	for _, include := range []string{"index", "translit", "rowid,index"} {
+		assertError(t, serve(http.MethodGet, "/search/TEST?q=and&stream=true&include="+include, ""), http.StatusBadRequest, errCodeInvalidParameter)
+	}
+}
+
+func TestSearchStreamUnderRequestTimeout(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+	saved := requestTimeout
+	requestTimeout = time.Minute
+	t.Cleanup(func() { requestTimeout = saved })
+
+	// The timeout wrapper would buffer the body and drop the flushes
+	rec := serve(http.MethodGet, "/search/TEST?q=and&stream=true", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status = %d, Content-Type = %q (body %s)", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	if !rec.Flushed {
+		t.Error("stream was not flushed through the timeout middleware")
+	}
+	if lines := strings.Count(rec.Body.String(), "\n"); lines != 3 {
+		t.Errorf("got %d lines, want 3", lines)
+	}
+}
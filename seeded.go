@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"net/http"
+)
+
+// Map a seed to a verse: its FNV-1a hash modulo the verse count picks a
+// canonical index, resolved to a book and an offset within it
+func seededPosition(seed string, counts []BookVerseCount) (book, offset int, ok bool) {
+	total := 0
+	for _, c := range counts {
+		total += c.Verses
+	}
+	if total == 0 {
+		return 0, 0, false
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	index := int(h.Sum64() % uint64(total))
+
+	for _, c := range counts {
+		if index < c.Verses {
+			return c.BookNumber, index, true
+		}
+		index -= c.Verses
+	}
+	return 0, 0, false
+}
+
+// Resolve a seed to its verse. The verse counts are cached and go stale if a
+// database file shrinks without a reload, leaving the offset past the last
+// row; on a miss they are recounted and the pick retried once. Returns nil
+// when there is still no verse.
+func seededVerse(ctx context.Context, store VerseStore, translationName, seed string) (*VerseResponse, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			log.Printf("Warning: Verse counts for %s look stale, recounting", translationName)
+			forgetBookVerseCounts(translationName)
+		}
+		counts, err := store.BookVerseCounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		book, offset, ok := seededPosition(seed, counts)
+		if !ok {
+			continue
+		}
+		verse, err := store.VerseAt(ctx, book, offset)
+		if err != nil || verse != nil {
+			return verse, err
+		}
+	}
+	return nil, nil
+}
+
+// Get the verse for a seed handler; the same seed always yields the same verse
+// of a translation, on every instance
+func seededVerseHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	seed := r.URL.Query().Get("seed")
+	if seed == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'seed' is required", http.StatusBadRequest)
+		return
+	}
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	transforms, err := parseTransforms(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strongs, err := parseStrongs(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verse, err := seededVerse(r.Context(), store, translationName, seed)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	if verse == nil {
+		respondWithError(w, errCodeVerseNotFound, "Verse not found", http.StatusNotFound)
+		return
+	}
+	if transforms != nil || strongs != strongsStrip {
+		verse.Text = renderText(verse.rawText, verse.BookNumber, transforms, strongs)
+	}
+
+	if err := applyIncludes(r.Context(), store, verse, includes); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verse)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSeededVerseRecountsStaleCounts(t *testing.T) {
+	var verses []testVerse
+	for verse := 1; verse <= 20; verse++ {
+		verses = append(verses, testVerse{10, 1, verse, fmt.Sprintf("Verse %d.", verse)})
+	}
+	path := newTestDatabase(t, "en", verses)
+	loadTestTranslations(t, map[string]string{"TEST": path})
+
+	dbMutex.RLock()
+	store := &sqliteStore{name: "TEST", db: dbPool["TEST"]}
+	dbMutex.RUnlock()
+	ctx := context.Background()
+
+	// Warm the count cache, then shrink the file behind it
+	stale, err := store.BookVerseCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writable, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writable.Close()
+	if _, err := writable.Exec("DELETE FROM verses WHERE verse > 5"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A seed whose stale position lies past the rows that are left
+	var seed string
+	var offset int
+	for i := 0; seed == ""; i++ {
+		candidate := fmt.Sprintf("seed-%d", i)
+		if _, at, _ := seededPosition(candidate, stale); at >= 5 {
+			seed, offset = candidate, at
+		}
+	}
+	if verse, err := store.VerseAt(ctx, 10, offset); err != nil || verse != nil {
+		t.Fatalf("stale pick = %+v, %v, want no verse", verse, err)
+	}
+
+	verse, err := seededVerse(ctx, store, "TEST", seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verse == nil || verse.Verse > 5 {
+		t.Fatalf("seededVerse() = %+v, want one of the remaining verses", verse)
+	}
+
+	counts, err := store.BookVerseCounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 1 || counts[0].Verses != 5 {
+		t.Fatalf("counts after the retry = %+v, want 5 verses", counts)
+	}
+
+	// Still empty after the recount: a plain 404
+	if _, err := writable.Exec("DELETE FROM verses"); err != nil {
+		t.Fatal(err)
+	}
+	if verse, err := seededVerse(ctx, store, "TEST", seed); err != nil || verse != nil {
+		t.Fatalf("seededVerse() on an empty file = %+v, %v, want nil", verse, err)
+	}
+	assertError(t, serve(http.MethodGet, "/seeded-verse/TEST?seed="+seed, ""), http.StatusNotFound, errCodeVerseNotFound)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+)
+
+type PlanChapter struct {
+	BookNumber     int    `json:"book_number"`
+	BookTitle      string `json:"book_title"`
+	BookTitleShort string `json:"book_title_short"`
+	Chapter        int    `json:"chapter"`
+	Verses         int    `json:"verses"`
+}
+
+type ShufflePlanResponse struct {
+	Translation string        `json:"translation"`
+	Seed        string        `json:"seed"`
+	Day         int           `json:"day"`
+	PerDay      int           `json:"per_day"`
+	TotalDays   int           `json:"total_days"`
+	Chapters    []PlanChapter `json:"chapters"`
+}
+
+// Chapters per day of a shuffled reading plan
+const (
+	defaultPlanChaptersPerDay = 3
+	maxPlanChaptersPerDay     = 50
+)
+
+// Shuffle chapters with Fisher–Yates driven by a PRNG seeded from the seed's
+// FNV-1a hash; math/rand's seeded sequences are stable across Go releases,
+// so a seed maps to the same order everywhere
+func shuffleChapters(seed string, chapters []chapterKey) {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	for i := len(chapters) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		chapters[i], chapters[j] = chapters[j], chapters[i]
+	}
+}
+
+// Get one day of a reading plan through every chapter in seeded random order handler
+func shufflePlanHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	params := r.URL.Query()
+
+	seed := params.Get("seed")
+	if seed == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'seed' is required", http.StatusBadRequest)
+		return
+	}
+	day, err := parseIntParam(params.Get("day"), 1, 1, -1)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid day: %v", err), http.StatusBadRequest)
+		return
+	}
+	perDay, err := parseIntParam(params.Get("per_day"), defaultPlanChaptersPerDay, 1, maxPlanChaptersPerDay)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid per_day: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	counts, err := chapterVerseCounts(r.Context(), db, 0)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to build reading plan", http.StatusInternalServerError)
+		return
+	}
+	books, err := getOutline(r.Context(), translationName, db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to build reading plan", http.StatusInternalServerError)
+		return
+	}
+
+	chapters := sortedChapters(counts)
+	shuffleChapters(seed, chapters)
+
+	totalDays := (len(chapters) + perDay - 1) / perDay
+	if day > totalDays {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Invalid day: the plan has %d days", totalDays), http.StatusBadRequest)
+		return
+	}
+
+	titles := make(map[int]OutlineBook, len(books))
+	for _, book := range books {
+		titles[book.BookNumber] = book
+	}
+
+	start := (day - 1) * perDay
+	end := min(start+perDay, len(chapters))
+	assigned := make([]PlanChapter, 0, end-start)
+	for _, key := range chapters[start:end] {
+		assigned = append(assigned, PlanChapter{
+			BookNumber:     key.book,
+			BookTitle:      titles[key.book].LongName,
+			BookTitleShort: titles[key.book].ShortName,
+			Chapter:        key.chapter,
+			Verses:         counts[key],
+		})
+	}
+
+	writeJSON(w, http.StatusOK, ShufflePlanResponse{
+		Translation: translationName,
+		Seed:        seed,
+		Day:         day,
+		PerDay:      perDay,
+		TotalDays:   totalDays,
+		Chapters:    assigned,
+	})
+}
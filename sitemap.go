@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type SitemapEntry struct {
+	Loc string `xml:"loc"`
+}
+
+type SitemapURLSet struct {
+	XMLName xml.Name       `xml:"urlset"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	URLs    []SitemapEntry `xml:"url"`
+}
+
+type SitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	Sitemaps []SitemapEntry `xml:"sitemap"`
+}
+
+// Most URLs a single sitemap may list under the sitemap protocol
+const sitemapMaxURLs = 50000
+
+// Namespace of sitemap and sitemap index documents
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Always serve /sitemap.xml as an index of per-translation sitemaps
+// (SITEMAP_INDEX); otherwise the index is used only past sitemapMaxURLs
+var sitemapIndexEnabled = false
+
+// Chapter URLs of a translation in canonical order
+func sitemapChapterURLs(r *http.Request, translationName string, db *sql.DB) ([]SitemapEntry, error) {
+	counts, err := chapterVerseCounts(r.Context(), db, 0)
+	if err != nil {
+		return nil, err
+	}
+	keys := sortedChapters(counts)
+
+	base := requestBaseURL(r)
+	entries := make([]SitemapEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = SitemapEntry{Loc: fmt.Sprintf("%s/get-chapter/%s/%d/%d/", base, translationName, key.book, key.chapter)}
+	}
+	return entries, nil
+}
+
+// Write an XML document with its declaration
+func respondWithXML(w http.ResponseWriter, document interface{}) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(document)
+}
+
+// Get the sitemap of every chapter across loaded translations handler; an
+// index of per-translation sitemaps when enabled or when one would be too large
+func sitemapHandler(w http.ResponseWriter, r *http.Request) {
+	dbMutex.RLock()
+	databases := make(map[string]*sql.DB, len(dbPool))
+	names := make([]string, 0, len(dbPool))
+	for name, db := range dbPool {
+		databases[name] = db
+		names = append(names, name)
+	}
+	dbMutex.RUnlock()
+	sort.Strings(names)
+
+	urls := make([]SitemapEntry, 0)
+	for _, name := range names {
+		entries, err := sitemapChapterURLs(r, name, databases[name])
+		if err != nil {
+			logQueryError(name, err)
+			respondWithError(w, errCodeInternal, "Failed to build sitemap", http.StatusInternalServerError)
+			return
+		}
+		urls = append(urls, entries...)
+	}
+
+	if sitemapIndexEnabled || len(urls) > sitemapMaxURLs {
+		base := requestBaseURL(r)
+		index := SitemapIndex{Xmlns: sitemapNamespace, Sitemaps: make([]SitemapEntry, len(names))}
+		for i, name := range names {
+			index.Sitemaps[i] = SitemapEntry{Loc: fmt.Sprintf("%s/sitemaps/%s.xml", base, name)}
+		}
+		respondWithXML(w, index)
+		return
+	}
+
+	respondWithXML(w, SitemapURLSet{Xmlns: sitemapNamespace, URLs: urls})
+}
+
+// Get the chapter sitemap of one translation handler (/sitemaps/{TRANSLATION}.xml)
+func translationSitemapHandler(w http.ResponseWriter, r *http.Request) {
+	translationName, found := strings.CutSuffix(r.PathValue("file"), ".xml")
+	if !found {
+		respondWithError(w, errCodeNotFound, "Sitemap not found", http.StatusNotFound)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	urls, err := sitemapChapterURLs(r, translationName, db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to build sitemap", http.StatusInternalServerError)
+		return
+	}
+
+	respondWithXML(w, SitemapURLSet{Xmlns: sitemapNamespace, URLs: urls})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+)
+
+type BookStats struct {
+	BookNumber int    `json:"book_number"`
+	ShortName  string `json:"short_name"`
+	LongName   string `json:"long_name"`
+	Verses     int    `json:"verses"`
+	Longest    bool   `json:"longest,omitempty"`
+	Shortest   bool   `json:"shortest,omitempty"`
+}
+
+type BookStatsResponse struct {
+	Translation string      `json:"translation"`
+	Books       []BookStats `json:"books"`
+}
+
+type LengthBucket struct {
+	Min    int `json:"min"`
+	Max    int `json:"max"`
+	Verses int `json:"verses"`
+}
+
+type VerseLengthsResponse struct {
+	Translation string         `json:"translation"`
+	BucketSize  int            `json:"bucket_size"`
+	Verses      int            `json:"verses"`
+	Shortest    int            `json:"shortest"`
+	Longest     int            `json:"longest"`
+	Average     float64        `json:"average"`
+	Buckets     []LengthBucket `json:"buckets"`
+}
+
+// Get every book's verse count with the longest and shortest marked handler
+func bookStatsHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT b.book_number, b.short_name, b.long_name, COUNT(*)
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		GROUP BY v.book_number
+		ORDER BY v.book_number
+	`)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve book statistics", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	books := make([]BookStats, 0)
+	for rows.Next() {
+		var book BookStats
+		if err := rows.Scan(&book.BookNumber, &book.ShortName, &book.LongName, &book.Verses); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve book statistics", http.StatusInternalServerError)
+			return
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve book statistics", http.StatusInternalServerError)
+		return
+	}
+
+	// Mark every book tied for the maximum or minimum
+	if len(books) > 0 {
+		longest, shortest := books[0].Verses, books[0].Verses
+		for _, book := range books {
+			longest = max(longest, book.Verses)
+			shortest = min(shortest, book.Verses)
+		}
+		for i := range books {
+			books[i].Longest = books[i].Verses == longest
+			books[i].Shortest = books[i].Verses == shortest
+		}
+	}
+
+	writeJSON(w, http.StatusOK, BookStatsResponse{
+		Translation: translationName,
+		Books:       books,
+	})
+}
+
+// Width, in characters of cleaned text, of each verse length histogram bucket
+const verseLengthBucketSize = 25
+
+// Per-translation verse length histogram, computed on first request
+var verseLengthsCache = make(map[string]*VerseLengthsResponse)
+var verseLengthsMutex sync.Mutex
+
+// Bucket the cleaned length of every verse; lengths are counted after clearText
+// since the stored text carries markup
+func getVerseLengths(ctx context.Context, translationName string, db *sql.DB) (*VerseLengthsResponse, error) {
+	verseLengthsMutex.Lock()
+	defer verseLengthsMutex.Unlock()
+
+	if lengths, ok := verseLengthsCache[translationName]; ok {
+		recordCacheResult(ctx, true)
+		return lengths, nil
+	}
+	recordCacheResult(ctx, false)
+
+	rows, err := db.QueryContext(ctx, "SELECT text FROM verses")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lengths := &VerseLengthsResponse{Translation: translationName, BucketSize: verseLengthBucketSize, Buckets: make([]LengthBucket, 0)}
+	total := 0
+	for rows.Next() {
+		var rawText string
+		if err := rows.Scan(&rawText); err != nil {
+			return nil, err
+		}
+		length := utf8.RuneCountInString(clearText(rawText))
+
+		bucket := length / verseLengthBucketSize
+		for len(lengths.Buckets) <= bucket {
+			start := len(lengths.Buckets) * verseLengthBucketSize
+			lengths.Buckets = append(lengths.Buckets, LengthBucket{Min: start, Max: start + verseLengthBucketSize - 1})
+		}
+		lengths.Buckets[bucket].Verses++
+
+		if lengths.Verses == 0 || length < lengths.Shortest {
+			lengths.Shortest = length
+		}
+		lengths.Longest = max(lengths.Longest, length)
+		lengths.Verses++
+		total += length
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if lengths.Verses > 0 {
+		lengths.Average = math.Round(float64(total)/float64(lengths.Verses)*10) / 10
+	}
+
+	verseLengthsCache[translationName] = lengths
+	return lengths, nil
+}
+
+// Get the verse length histogram of a translation handler
+func verseLengthsHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	lengths, err := getVerseLengths(r.Context(), translationName, db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse lengths", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lengths)
+}
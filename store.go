@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Verse lookups shared by the verse, chapter, random, seeded and search
+// handlers, so those can move to another storage backend without rewriting
+// them. Endpoints built on queries outside this set (book lists, navigation,
+// cross-references, regex and statistics among them) still take the
+// *sql.DB from lookupDatabase.
+type VerseStore interface {
+	// A random verse, optionally limited to one book (0 for any) and skipping
+	// excluded references; nil when no verse is left
+	RandomVerse(ctx context.Context, book int, exclude []Reference) (*VerseResponse, error)
+	// The verse at a 0-based offset within a book in canonical order; nil past its end
+	VerseAt(ctx context.Context, book, offset int) (*VerseResponse, error)
+	// The verses for refs in order, with nil entries for misses
+	GetVerses(ctx context.Context, refs []Reference) ([]*VerseResponse, error)
+	// A whole chapter; nil when it does not exist
+	Chapter(ctx context.Context, book, chapter int) (*ChapterResponse, error)
+	// Verses whose normalized text contains the query, in canonical order
+	Search(ctx context.Context, query searchQuery) ([]VerseResponse, error)
+	// Like Search, but hands each verse to fn as it is read; stops at fn's first error
+	SearchEach(ctx context.Context, query searchQuery, fn func(VerseResponse) error) error
+	// The 1-based position of a verse in canonical order
+	GlobalIndex(ctx context.Context, ref Reference) (int, error)
+	// Verse counts per book, in book order
+	BookVerseCounts(ctx context.Context) ([]BookVerseCount, error)
+	// The cleaned transliteration of a verse; "" when the module stores none
+	Transliteration(ctx context.Context, ref Reference) (string, error)
+}
+
+// Parameters of a text search; a negative Limit returns every match
+type searchQuery struct {
+	Text          string
+	Language      string
+	IgnoreAccents bool
+	Limit         int
+	Offset        int
+	// Only verses after this reference when set (cursor paging)
+	After *Reference
+}
+
+// VerseStore backed by a MyBible SQLite database
+type sqliteStore struct {
+	name string
+	db   *sql.DB
+}
+
+// Resolve a translation to its store, responding with an error when it cannot be served
+func lookupStore(w http.ResponseWriter, translationName string) (VerseStore, bool) {
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return nil, false
+	}
+	return &sqliteStore{name: translationName, db: db}, true
+}
+
+func (s *sqliteStore) RandomVerse(ctx context.Context, book int, exclude []Reference) (*VerseResponse, error) {
+	query := `
+		SELECT v.rowid, v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		%s
+		ORDER BY RANDOM()
+		LIMIT 1
+	`
+	var conditions []string
+	var args []interface{}
+
+	if book > 0 {
+		conditions = append(conditions, "v.book_number = ?")
+		args = append(args, book)
+	}
+
+	// Skip verses the client has already shown
+	if len(exclude) > 0 {
+		placeholders := make([]string, len(exclude))
+		for i, ref := range exclude {
+			placeholders[i] = "(?, ?, ?)"
+			args = append(args, ref.Book, ref.Chapter, ref.Verse)
+		}
+		conditions = append(conditions, fmt.Sprintf("(v.book_number, v.chapter, v.verse) NOT IN (VALUES %s)", strings.Join(placeholders, ", ")))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var verse VerseResponse
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(query, where), args...).Scan(
+		&verse.rowID,
+		&verse.BookNumber,
+		&verse.Chapter,
+		&verse.Verse,
+		&verse.rawText,
+		&verse.BookTitleShort,
+		&verse.BookTitle,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verse.Text = clearText(verse.rawText)
+	verse.Translation = s.name
+	return &verse, nil
+}
+
+func (s *sqliteStore) VerseAt(ctx context.Context, book, offset int) (*VerseResponse, error) {
+	var verse VerseResponse
+	err := s.db.QueryRowContext(ctx, `
+		SELECT v.rowid, v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE v.book_number = ?
+		ORDER BY v.chapter, v.verse
+		LIMIT 1 OFFSET ?
+	`, book, offset).Scan(
+		&verse.rowID,
+		&verse.BookNumber,
+		&verse.Chapter,
+		&verse.Verse,
+		&verse.rawText,
+		&verse.BookTitleShort,
+		&verse.BookTitle,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	verse.Text = clearText(verse.rawText)
+	verse.Translation = s.name
+	return &verse, nil
+}
+
+func (s *sqliteStore) GetVerses(ctx context.Context, refs []Reference) ([]*VerseResponse, error) {
+	return fetchVerses(ctx, s.db, s.name, refs)
+}
+
+func (s *sqliteStore) Chapter(ctx context.Context, book, chapter int) (*ChapterResponse, error) {
+	return fetchChapter(ctx, s.db, s.name, book, chapter)
+}
+
+func (s *sqliteStore) Search(ctx context.Context, query searchQuery) ([]VerseResponse, error) {
+	results := make([]VerseResponse, 0, max(query.Limit, 0))
+	err := s.SearchEach(ctx, query, func(verse VerseResponse) error {
+		results = append(results, verse)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *sqliteStore) SearchEach(ctx context.Context, query searchQuery, fn func(VerseResponse) error) error {
+	pattern := "%" + escapeLike(normalizeSearchText(query.Text, query.Language, query.IgnoreAccents)) + "%"
+
+	after := ""
+	args := []interface{}{query.Language, query.IgnoreAccents, pattern}
+	if query.After != nil {
+		after = "AND (v.book_number, v.chapter, v.verse) > (?, ?, ?)"
+		args = append(args, query.After.Book, query.After.Chapter, query.After.Verse)
+	}
+	args = append(args, query.Limit, query.Offset)
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT v.rowid, v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE normalize_text(v.text, ?, ?) LIKE ? ESCAPE '\' %s
+		ORDER BY v.book_number, v.chapter, v.verse
+		LIMIT ? OFFSET ?
+	`, after), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var verse VerseResponse
+		if err := rows.Scan(&verse.rowID, &verse.BookNumber, &verse.Chapter, &verse.Verse, &verse.rawText, &verse.BookTitleShort, &verse.BookTitle); err != nil {
+			return err
+		}
+		verse.Text = clearText(verse.rawText)
+		verse.Translation = s.name
+		if err := fn(verse); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *sqliteStore) GlobalIndex(ctx context.Context, ref Reference) (int, error) {
+	return globalIndex(ctx, s.db, ref.Book, ref.Chapter, ref.Verse)
+}
+
+func (s *sqliteStore) BookVerseCounts(ctx context.Context) ([]BookVerseCount, error) {
+	return getBookVerseCounts(ctx, s.name, s.db)
+}
+
+func (s *sqliteStore) Transliteration(ctx context.Context, ref Reference) (string, error) {
+	dbMutex.RLock()
+	source := translationMetadata[s.name].Translit
+	dbMutex.RUnlock()
+
+	var query string
+	switch source {
+	case translitColumn:
+		query = "SELECT translit FROM verses WHERE book_number = ? AND chapter = ? AND verse = ?"
+	case translitTable:
+		query = "SELECT text FROM transliterations WHERE book_number = ? AND chapter = ? AND verse = ?"
+	default:
+		return "", nil
+	}
+
+	var text sql.NullString
+	err := s.db.QueryRowContext(ctx, query, ref.Book, ref.Chapter, ref.Verse).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return clearText(text.String), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reject unknown query parameters on every request (STRICT_PARAMS)
+var strictParamsEnabled = false
+
+// Parameters every route accepts, handled by the middleware chain
+var globalParams = []string{"debug", "strict"}
+
+// Check whether a request opted into strict mode; ?strict=true enables it for one request
+func strictRequested(r *http.Request) bool {
+	if value := r.URL.Query().Get("strict"); value != "" {
+		strict, err := strconv.ParseBool(value)
+		return err == nil && strict
+	}
+	return strictParamsEnabled
+}
+
+// Strict mode middleware: in strict mode a request naming query parameters
+// the route does not accept is rejected with 400 instead of ignoring them
+func strictParamsMiddleware(accepted []string, next http.HandlerFunc) http.HandlerFunc {
+	known := make(map[string]bool, len(accepted)+len(globalParams))
+	for _, name := range params(accepted, globalParams) {
+		known[name] = true
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strictRequested(r) {
+			next(w, r)
+			return
+		}
+
+		var unknown []string
+		for name := range r.URL.Query() {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unknown query parameters: %s", strings.Join(unknown, ", ")), http.StatusBadRequest)
+			return
+		}
+		next(w, r)
+	}
+}
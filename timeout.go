@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Overall deadline for a handler to finish its response (REQUEST_TIMEOUT, 0 disables it)
+var requestTimeout time.Duration
+
+// Wrap a handler in http.TimeoutHandler so a stuck handler answers 503 with the
+// usual JSON error body instead of holding the connection open
+func timeoutMiddleware(next http.Handler, timeout time.Duration) http.HandlerFunc {
+	body, _ := json.Marshal(ErrorResponse{Error: "Request timed out", Code: errCodeTimeout})
+	timeoutHandler := http.TimeoutHandler(next, timeout, string(body))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// TimeoutHandler buffers the whole body, so streams only get the
+		// deadline on their context and end early once it passes
+		if streamingRequest(r) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		// The timeout response only carries headers set on w beforehand;
+		// completed responses overwrite this with their own Content-Type
+		w.Header().Set("Content-Type", "application/json")
+		timeoutHandler.ServeHTTP(w, r)
+	}
+}
+
+// Whether the request asks for a streamed search
+func streamingRequest(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, "/search/") {
+		return false
+	}
+	stream, _ := strconv.ParseBool(r.URL.Query().Get("stream"))
+	return stream
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+)
+
+type TOCChapter struct {
+	Chapter int `json:"chapter"`
+	Verses  int `json:"verses"`
+}
+
+type TOCBook struct {
+	BookNumber int          `json:"book_number"`
+	ShortName  string       `json:"short_name"`
+	LongName   string       `json:"long_name"`
+	Verses     int          `json:"verses"`
+	Chapters   []TOCChapter `json:"chapters"`
+}
+
+type TOCResponse struct {
+	Translation string    `json:"translation"`
+	Books       []TOCBook `json:"books"`
+}
+
+// Cache-Control of the table of contents; it only changes when the databases do
+const tocCacheControl = "public, max-age=86400"
+
+// Per-translation encoded table of contents, built on first request
+var tocCache = make(map[string][]byte)
+var tocMutex sync.Mutex
+
+// Build the books → chapters → verse counts tree of a translation, cached
+// encoded since it never changes at runtime
+func getTOC(ctx context.Context, translationName string, db *sql.DB) ([]byte, error) {
+	tocMutex.Lock()
+	defer tocMutex.Unlock()
+
+	if body, ok := tocCache[translationName]; ok {
+		recordCacheResult(ctx, true)
+		return body, nil
+	}
+	recordCacheResult(ctx, false)
+
+	books, err := getOutline(ctx, translationName, db)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT book_number, chapter, COUNT(*) FROM verses GROUP BY book_number, chapter ORDER BY book_number, chapter")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chapters := make(map[int][]TOCChapter)
+	for rows.Next() {
+		var book int
+		var chapter TOCChapter
+		if err := rows.Scan(&book, &chapter.Chapter, &chapter.Verses); err != nil {
+			return nil, err
+		}
+		chapters[book] = append(chapters[book], chapter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	toc := TOCResponse{Translation: translationName, Books: make([]TOCBook, 0, len(books))}
+	for _, book := range books {
+		entry := TOCBook{
+			BookNumber: book.BookNumber,
+			ShortName:  book.ShortName,
+			LongName:   book.LongName,
+			Chapters:   chapters[book.BookNumber],
+		}
+		for _, chapter := range entry.Chapters {
+			entry.Verses += chapter.Verses
+		}
+		toc.Books = append(toc.Books, entry)
+	}
+
+	body, err := encodeJSON(toc)
+	if err != nil {
+		return nil, err
+	}
+	tocCache[translationName] = body
+	return body, nil
+}
+
+// Get the whole table of contents of a translation handler
+func tocHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	body, err := getTOC(r.Context(), translationName, db)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve table of contents", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", tocCacheControl)
+	writeJSONBytes(w, http.StatusOK, body)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type Footnote struct {
+	Verse  int    `json:"verse,omitempty"`
+	Marker string `json:"marker"`
+	Text   string `json:"text"`
+}
+
+// A named step of verse text post-processing
+type textTransform struct {
+	name  string
+	apply func(string) string
+}
+
+var strongsRegex = regexp.MustCompile(`<S>\d+</S>`)
+
+// Markup tags other than italics and Strong's numbers
+var markupRegex = regexp.MustCompile(`</?[^ai <>]+/?>`)
+
+// Extra patterns removed together with their content by strip_custom
+// (env CLEAN_PATTERNS), for module-specific markup such as <f>...</f> footnotes
+var customCleanPatterns []*regexp.Regexp
+
+// Compile CLEAN_PATTERNS: one regular expression per line, blank lines ignored
+func parseCleanPatterns(value string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(line)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+var quoteReplacer = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`,
+)
+
+// Available transforms, in the order they run regardless of how they are requested
+var textTransforms = []textTransform{
+	{"normalize_nfc", norm.NFC.String},
+	{"strip_strongs", func(text string) string {
+		return strongsRegex.ReplaceAllString(text, "")
+	}},
+	{"strip_custom", func(text string) string {
+		for _, pattern := range customCleanPatterns {
+			text = pattern.ReplaceAllString(text, "")
+		}
+		return text
+	}},
+	{"strip_markup", func(text string) string {
+		// Whole <S>n</S> numbers are left to strip_strongs; stray <S> tags go
+		var out strings.Builder
+		last := 0
+		for _, m := range strongsRegex.FindAllStringIndex(text, -1) {
+			out.WriteString(markupRegex.ReplaceAllString(text[last:m[0]], ""))
+			out.WriteString(text[m[0]:m[1]])
+			last = m[1]
+		}
+		out.WriteString(markupRegex.ReplaceAllString(text[last:], ""))
+		return out.String()
+	}},
+	{"strip_italics", func(text string) string {
+		return strings.NewReplacer("<i>", "", "</i>", "").Replace(text)
+	}},
+	{"normalize_quotes", quoteReplacer.Replace},
+	{"collapse_whitespace", func(text string) string {
+		return whitespaceRegex.ReplaceAllString(strings.TrimSpace(text), " ")
+	}},
+}
+
+// Transforms applied when a request does not choose its own (the clearText
+// behaviour); NORMALIZE_NFC=false drops normalize_nfc from the set
+var defaultTransforms = map[string]bool{
+	"normalize_nfc":       true,
+	"strip_strongs":       true,
+	"strip_custom":        true,
+	"strip_markup":        true,
+	"collapse_whitespace": true,
+}
+
+// Run the selected transforms over raw verse text
+func applyTransforms(text string, selected map[string]bool) string {
+	for _, transform := range textTransforms {
+		if selected[transform.name] {
+			text = transform.apply(text)
+		}
+	}
+	return text
+}
+
+// Parse ?transforms=a,b into a set of transform names; "default" stands for
+// the default set. Returns nil when the parameter is absent.
+func parseTransforms(r *http.Request) (map[string]bool, error) {
+	value := r.URL.Query().Get("transforms")
+	if value == "" {
+		return nil, nil
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "default" {
+			for defaultName := range defaultTransforms {
+				selected[defaultName] = true
+			}
+			continue
+		}
+		known := false
+		for _, transform := range textTransforms {
+			if transform.name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("Unknown transform '%s'", name)
+		}
+		selected[name] = true
+	}
+	return selected, nil
+}
+
+// Supported values for ?strongs
+const (
+	strongsStrip  = "strip"
+	strongsInline = "inline"
+)
+
+// A Strong's number tag together with the whitespace before it
+var strongsTagRegex = regexp.MustCompile(`\s*<S>(\d+)</S>`)
+
+// Parse ?strongs (default strip, which leaves Strong's numbers to the transforms)
+func parseStrongs(r *http.Request) (string, error) {
+	value := r.URL.Query().Get("strongs")
+	if value == "" {
+		return strongsStrip, nil
+	}
+	if value != strongsStrip && value != strongsInline {
+		return "", fmt.Errorf("Invalid strongs '%s', expected strip or inline", value)
+	}
+	return value, nil
+}
+
+// Replace each <S>n</S> with a [Hn] (Old Testament) or [Gn] marker attached
+// to the word it follows, e.g. "earth.<S>776</S>" becomes "earth[H776]."
+func inlineStrongs(text string, book int) string {
+	prefix := "G"
+	if book < 470 {
+		prefix = "H"
+	}
+
+	var out []byte
+	last := 0
+	for _, m := range strongsTagRegex.FindAllStringSubmatchIndex(text, -1) {
+		out = append(out, text[last:m[0]]...)
+		marker := "[" + prefix + text[m[2]:m[3]] + "]"
+		at := wordEnd(out)
+		out = append(out[:at], append([]byte(marker), out[at:]...)...)
+		last = m[1]
+	}
+	return string(append(out, text[last:]...))
+}
+
+// Position right after the last word in text, stepping back over trailing
+// punctuation, whitespace and tags; an earlier marker counts as part of the
+// word so consecutive numbers keep their order
+func wordEnd(text []byte) int {
+	i := len(text)
+	for i > 0 {
+		r, size := utf8.DecodeLastRune(text[:i])
+		switch {
+		case r == '>':
+			open := bytes.LastIndexByte(text[:i], '<')
+			if open < 0 {
+				return i
+			}
+			i = open
+		case unicode.IsSpace(r) || (unicode.IsPunct(r) && r != ']'):
+			i -= size
+		default:
+			return i
+		}
+	}
+	return len(text)
+}
+
+// Render raw verse text for a request: Strong's mode first, then the chosen
+// transforms (the default set when none were requested)
+func renderText(raw string, book int, transforms map[string]bool, strongs string) string {
+	if strongs == strongsInline {
+		raw = inlineStrongs(raw, book)
+	}
+	if transforms == nil {
+		transforms = defaultTransforms
+	}
+	return applyTransforms(raw, transforms)
+}
+
+// Inline footnote markup: <f>content</f>
+var footnoteRegex = regexp.MustCompile(`(?s)<f>(.*?)</f>`)
+
+// Letter marker of the n-th footnote (0-based): a … z, aa, ab, …
+func footnoteMarker(n int) string {
+	marker := ""
+	for n >= 0 {
+		marker = string(rune('a'+n%26)) + marker
+		n = n/26 - 1
+	}
+	return marker
+}
+
+// Replace each inline footnote with a bracketed letter marker such as "[a]",
+// numbering on from count, and return the cleaned footnote contents. Runs on
+// raw text before the transforms, which would otherwise strip the tags and
+// leave the contents in the verse.
+func extractFootnotes(text string, verse, count int) (string, []Footnote) {
+	footnotes := make([]Footnote, 0)
+	text = footnoteRegex.ReplaceAllStringFunc(text, func(match string) string {
+		marker := footnoteMarker(count + len(footnotes))
+		content := footnoteRegex.FindStringSubmatch(match)[1]
+		footnotes = append(footnotes, Footnote{Verse: verse, Marker: marker, Text: clearText(content)})
+		return "[" + marker + "]"
+	})
+	return text, footnotes
+}
+
+// Parse ?footnotes (default false)
+func parseFootnotes(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("footnotes")
+	if value == "" {
+		return false, nil
+	}
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid footnotes value")
+	}
+	return enabled, nil
+}
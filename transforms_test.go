@@ -0,0 +1,197 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTextTransforms(t *testing.T) {
+	tests := []struct {
+		transform string
+		text      string
+		want      string
+	}{
+		{"normalize_nfc", "Cafe\u0301 \u0438\u0306", "Caf\u00e9 \u0439"},
+		{"normalize_nfc", "already composed", "already composed"},
+		{"strip_strongs", "God<S>430</S> created<S>1254</S>", "God created"},
+		{"strip_custom", "left <f>as is</f>", "left <f>as is</f>"},
+		{"strip_markup", "<J>Jesus</J> wept.<br/>", "Jesus wept."},
+		{"strip_markup", "<i>his</i> word<S>1697</S>", "<i>his</i> word<S>1697</S>"},
+		{"strip_markup", "God<S>H430</S> word<S>1697</S>", "GodH430 word<S>1697</S>"},
+		{"strip_italics", "<i>his</i> epistles", "his epistles"},
+		{"normalize_quotes", "“Let there be” ‘light’ „here‟", `"Let there be" 'light' "here"`},
+		{"collapse_whitespace", "  And \tGod\n  said ", "And God said"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transform, func(t *testing.T) {
+			if got := applyTransforms(tt.text, map[string]bool{tt.transform: true}); got != tt.want {
+				t.Errorf("%s(%q) = %q, want %q", tt.transform, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextTransformsCompose(t *testing.T) {
+	raw := "<S>7225</S>In the  <i>beginning</i> “God”<S>430</S>  <J>created</J>"
+	tests := []struct {
+		name     string
+		selected map[string]bool
+		want     string
+	}{
+		{"default set", defaultTransforms, "In the <i>beginning</i> “God” created"},
+		{"run in pipeline order", map[string]bool{"collapse_whitespace": true, "strip_strongs": true}, "In the <i>beginning</i> “God” <J>created</J>"},
+		{"everything", map[string]bool{
+			"normalize_nfc": true, "strip_strongs": true, "strip_custom": true, "strip_markup": true,
+			"strip_italics": true, "normalize_quotes": true, "collapse_whitespace": true,
+		}, `In the beginning "God" created`},
+		{"none", map[string]bool{}, raw},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyTransforms(raw, tt.selected); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got, want := clearText(raw), applyTransforms(raw, defaultTransforms); got != want {
+		t.Errorf("clearText = %q, want the default transforms %q", got, want)
+	}
+
+	// Stray Strong's tags are stripped as before the transforms existed
+	if got, want := applyTransforms("God<S>H430</S> created<S>1254</S>", defaultTransforms), "GodH430 created"; got != want {
+		t.Errorf("default set got %q, want %q", got, want)
+	}
+}
+
+func TestParseTransforms(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []string
+		wantNil bool
+		wantErr bool
+	}{
+		{query: "", wantNil: true},
+		{query: "transforms=strip_strongs,normalize_quotes", want: []string{"strip_strongs", "normalize_quotes"}},
+		{query: "transforms=default,strip_italics", want: []string{"normalize_nfc", "strip_strongs", "strip_custom", "strip_markup", "collapse_whitespace", "strip_italics"}},
+		{query: "transforms=strip_strongs,shout", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := parseTransforms(httptest.NewRequest("GET", "/?"+tt.query, nil))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("got %v, want nil", got)
+				}
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for _, name := range tt.want {
+				if !got[name] {
+					t.Errorf("missing %s in %v", name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInlineStrongs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		book int
+		want string
+	}{
+		{"Old Testament prefix", "In the beginning<S>7225</S> God<S>430</S>", 10, "In the beginning[H7225] God[H430]"},
+		{"New Testament prefix", "Jesus<S>2424</S> wept<S>1145</S>", 500, "Jesus[G2424] wept[G1145]"},
+		{"before punctuation", "the earth.<S>776</S>", 10, "the earth[H776]."},
+		{"after several marks", "light:<S>216</S> and", 10, "light[H216]: and"},
+		{"space before the tag", "created <S>1254</S> the", 10, "created[H1254] the"},
+		{"inside italics", "<i>was</i><S>1961</S> light", 10, "<i>was[H1961]</i> light"},
+		{"consecutive numbers keep order", "God<S>430</S><S>853</S> created", 10, "God[H430][H853] created"},
+		{"after a closing quote", "“light”<S>216</S>", 10, "“light[H216]”"},
+		{"Cyrillic word", "Бог<S>430</S>,", 10, "Бог[H430],"},
+		{"tag with no word before it", "<S>7225</S>In", 10, "[H7225]In"},
+		{"no numbers", "plain text.", 10, "plain text."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inlineStrongs(tt.text, tt.book); got != tt.want {
+				t.Errorf("inlineStrongs(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTextInlineStrongs(t *testing.T) {
+	// The markers survive the default transforms, which strip <S> tags
+	got := renderText("And God<S>430</S> said,<S>559</S> Let", 10, nil, strongsInline)
+	if want := "And God[H430] said[H559], Let"; got != want {
+		t.Errorf("renderText() = %q, want %q", got, want)
+	}
+}
+
+func TestParseCleanPatterns(t *testing.T) {
+	patterns, err := parseCleanPatterns("<f>.*?</f>\n\n  \\[\\d+\\]  \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 2 || patterns[0].String() != "<f>.*?</f>" || patterns[1].String() != `\[\d+\]` {
+		t.Fatalf("parseCleanPatterns() = %v, want the two trimmed patterns", patterns)
+	}
+
+	if _, err := parseCleanPatterns("<f>(.*</f>"); err == nil {
+		t.Fatal("parseCleanPatterns() accepted an invalid pattern")
+	}
+}
+
+func TestCustomCleanPatternStripsFootnotes(t *testing.T) {
+	patterns, err := parseCleanPatterns("<f>.*?</f>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := customCleanPatterns
+	customCleanPatterns = patterns
+	t.Cleanup(func() { customCleanPatterns = saved })
+
+	raw := "In the beginning<f>Or, At first</f> God<S>430</S> created<f>[1] made</f>."
+	if got, want := clearText(raw), "In the beginning God created."; got != want {
+		t.Errorf("clearText() = %q, want %q", got, want)
+	}
+
+	// Requests that leave strip_custom out keep the default behaviour
+	if got, want := applyTransforms(raw, map[string]bool{"strip_markup": true, "strip_strongs": true}), "In the beginningOr, At first God created[1] made."; got != want {
+		t.Errorf("without strip_custom got %q, want %q", got, want)
+	}
+}
+
+func TestExtractFootnotes(t *testing.T) {
+	text, notes := extractFootnotes("Light<f>Heb. <i>or</i></f> and dark<f>Gr. <S>4655</S>skotos</f>.", 3, 26)
+	if want := "Light[aa] and dark[ab]."; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	want := []Footnote{{Verse: 3, Marker: "aa", Text: "Heb. <i>or</i>"}, {Verse: 3, Marker: "ab", Text: "Gr. skotos"}}
+	if len(notes) != len(want) {
+		t.Fatalf("footnotes = %+v, want %+v", notes, want)
+	}
+	for i := range want {
+		if notes[i] != want[i] {
+			t.Errorf("footnote %d = %+v, want %+v", i, notes[i], want[i])
+		}
+	}
+
+	if text, notes := extractFootnotes("no notes", 1, 0); text != "no notes" || len(notes) != 0 {
+		t.Errorf("extractFootnotes() = %q, %+v, want the text unchanged", text, notes)
+	}
+}
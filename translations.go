@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type TranslationInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Direction   string `json:"direction,omitempty"`
+	Available   bool   `json:"available"`
+	SHA256      string `json:"sha256,omitempty"`
+}
+
+type SchemaColumn struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	NotNull    bool    `json:"not_null"`
+	Default    *string `json:"default"`
+	PrimaryKey bool    `json:"primary_key"`
+}
+
+type SchemaResponse struct {
+	Translation string                    `json:"translation"`
+	Tables      map[string][]SchemaColumn `json:"tables"`
+}
+
+// Metadata captured for each translation when its database is loaded
+type translationMeta struct {
+	Description string
+	SHA256      string
+	Direction   string
+	Translit    string
+	// Short identifier of the loaded file, sent as X-Translation-Version
+	Version string
+}
+
+// Send X-Translation-Version on translation responses (TRANSLATION_VERSION_HEADER)
+var translationVersionHeader = true
+
+// Identify a database file's contents: the first 16 hex digits of its
+// checksum, or its modification time when the checksum failed
+func translationVersion(path, checksum string) string {
+	if checksum != "" {
+		return checksum[:16]
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return "mtime-" + strconv.FormatInt(info.ModTime().Unix(), 10)
+}
+
+var translationMetadata = make(map[string]translationMeta)
+
+// Read a value from the module's info table ("" when absent)
+func readInfoValue(db *sql.DB, name string) string {
+	supported, err := hasTable(db, "info")
+	if err != nil || !supported {
+		return ""
+	}
+	var value string
+	if err := db.QueryRow("SELECT value FROM info WHERE name = ?", name).Scan(&value); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// Read the language tag recorded in the module's info table
+func detectLanguage(db *sql.DB) string {
+	return strings.ToLower(readInfoValue(db, "language"))
+}
+
+// Languages written right to left, used when the info table does not say
+var rightToLeftLanguages = map[string]bool{
+	"ar": true, "fa": true, "he": true, "syr": true, "ur": true, "yi": true,
+}
+
+// Text direction ("ltr" or "rtl") from the info table's right_to_left flag,
+// falling back to the language
+func detectDirection(db *sql.DB, language string) string {
+	if rtl, err := strconv.ParseBool(readInfoValue(db, "right_to_left")); err == nil {
+		if rtl {
+			return "rtl"
+		}
+		return "ltr"
+	}
+	if base, _, _ := strings.Cut(language, "-"); rightToLeftLanguages[base] {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// Where a module stores transliterated verse text, if anywhere
+const (
+	// Column verses.translit
+	translitColumn = "column"
+	// Table transliterations(book_number, chapter, verse, text)
+	translitTable = "table"
+)
+
+// Detect a module's transliteration source ("" when it has none)
+func detectTranslit(db *sql.DB) string {
+	columns, err := tableColumns(context.Background(), db, "verses")
+	if err == nil {
+		for _, column := range columns {
+			if column.Name == "translit" {
+				return translitColumn
+			}
+		}
+	}
+	if found, err := hasTable(db, "transliterations"); err == nil && found {
+		return translitTable
+	}
+	return ""
+}
+
+// Compute the SHA-256 of a database file
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// List configured translations with their metadata
+func translationsHandler(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(translations))
+	for name := range translations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dbMutex.RLock()
+	list := make([]TranslationInfo, 0, len(names))
+	for _, name := range names {
+		_, available := dbPool[name]
+		meta := translationMetadata[name]
+		list = append(list, TranslationInfo{
+			Name:        name,
+			Description: meta.Description,
+			Language:    translationLanguages[name],
+			Direction:   meta.Direction,
+			Available:   available,
+			SHA256:      meta.SHA256,
+		})
+	}
+	dbMutex.RUnlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"translations": list,
+	})
+}
+
+// Columns a database must have to be served
+var requiredColumns = map[string][]string{
+	"verses": {"book_number", "chapter", "verse", "text"},
+	"books":  {"book_number", "short_name", "long_name"},
+}
+
+// Check that the required tables and columns exist
+func validateSchema(db *sql.DB) error {
+	for _, table := range schemaTables {
+		columns, err := tableColumns(context.Background(), db, table)
+		if err != nil {
+			return err
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("missing table %q", table)
+		}
+		present := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			present[strings.ToLower(column.Name)] = true
+		}
+		for _, name := range requiredColumns[table] {
+			if !present[name] {
+				return fmt.Errorf("table %q is missing column %q", table, name)
+			}
+		}
+	}
+	return nil
+}
+
+// Tables described by the schema endpoint
+var schemaTables = []string{"verses", "books"}
+
+// Read a table's columns via PRAGMA table_info (empty when the table is missing)
+func tableColumns(ctx context.Context, db *sql.DB, table string) ([]SchemaColumn, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]SchemaColumn, 0)
+	for rows.Next() {
+		var column SchemaColumn
+		var defaultValue sql.NullString
+		var primaryKey int
+		if err := rows.Scan(&column.Name, &column.Type, &column.NotNull, &defaultValue, &primaryKey); err != nil {
+			return nil, err
+		}
+		if defaultValue.Valid {
+			column.Default = &defaultValue.String
+		}
+		column.PrimaryKey = primaryKey > 0
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// Describe the columns of a translation's verse and book tables
+func schemaHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	response := SchemaResponse{Translation: translationName, Tables: make(map[string][]SchemaColumn)}
+	for _, table := range schemaTables {
+		columns, err := tableColumns(r.Context(), db, table)
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to read schema", http.StatusInternalServerError)
+			return
+		}
+		response.Tables[table] = columns
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
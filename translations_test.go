@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Create a database file from statements and return its path
+func newMalformedDatabase(t *testing.T, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "malformed.SQLite3")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"valid module", newTestDatabase(t, "en", testGenesis), ""},
+		{"no verses table", newMalformedDatabase(t,
+			"CREATE TABLE books (book_number INTEGER, short_name TEXT, long_name TEXT)",
+		), `missing table "verses"`},
+		{"no books table", newMalformedDatabase(t,
+			"CREATE TABLE verses (book_number NUMERIC, chapter NUMERIC, verse NUMERIC, text TEXT)",
+		), `missing table "books"`},
+		{"missing column", newMalformedDatabase(t,
+			"CREATE TABLE verses (book_number NUMERIC, chapter NUMERIC, verse NUMERIC, content TEXT)",
+			"CREATE TABLE books (book_number INTEGER, short_name TEXT, long_name TEXT)",
+		), `table "verses" is missing column "text"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sql.Open("sqlite3", tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			err = validateSchema(db)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateSchema() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateSchema() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInitDatabasesSkipsMalformedFiles(t *testing.T) {
+	malformed := newMalformedDatabase(t, "CREATE TABLE books (book_number INTEGER, short_name TEXT, long_name TEXT)")
+	loadTestTranslations(t, map[string]string{
+		"TEST": newTestDatabase(t, "en", testGenesis),
+		"BAD":  malformed,
+	})
+
+	dbMutex.RLock()
+	_, good := dbPool["TEST"]
+	_, bad := dbPool["BAD"]
+	dbMutex.RUnlock()
+	if !good || bad {
+		t.Fatalf("loaded TEST=%v BAD=%v, want only TEST", good, bad)
+	}
+
+	// With nothing valid left the load fails as a whole
+	translations = map[string]string{"BAD": malformed}
+	if err := initDatabases(); err == nil {
+		t.Fatal("initDatabases() = nil, want an error when every file is malformed")
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type TextVariant struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+type VariantsResponse struct {
+	VerseResponse
+	Variants []TextVariant `json:"variants"`
+}
+
+// Optional table of footnotes and variant readings:
+// variants(book_number, chapter, verse, kind, text)
+const variantsTable = "variants"
+
+// Get a verse with its footnotes and variant readings handler
+func variantsHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	supported, err := hasTable(db, variantsTable)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve variants", http.StatusInternalServerError)
+		return
+	}
+	if !supported {
+		respondWithError(w, errCodeNotFound, fmt.Sprintf("Translation '%s' has no textual variants", translationName), http.StatusNotFound)
+		return
+	}
+
+	verses, err := fetchVerses(r.Context(), db, translationName, []Reference{ref})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve variants", http.StatusInternalServerError)
+		return
+	}
+	if verses[0] == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+
+	rows, err := db.QueryContext(r.Context(), `
+		SELECT kind, text FROM variants
+		WHERE book_number = ? AND chapter = ? AND verse = ?
+		ORDER BY rowid
+	`, ref.Book, ref.Chapter, ref.Verse)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve variants", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	variants := make([]TextVariant, 0)
+	for rows.Next() {
+		var variant TextVariant
+		var rawText string
+		if err := rows.Scan(&variant.Kind, &rawText); err != nil {
+			log.Printf("Database scan error for %s: %v", translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve variants", http.StatusInternalServerError)
+			return
+		}
+		variant.Text = clearText(rawText)
+		variants = append(variants, variant)
+	}
+	if err := rows.Err(); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve variants", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, VariantsResponse{
+		VerseResponse: *verses[0],
+		Variants:      variants,
+	})
+}
@@ -0,0 +1,571 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type VerseFootnotesResponse struct {
+	VerseResponse
+	Footnotes []Footnote `json:"footnotes"`
+}
+
+type SentencesResponse struct {
+	Translation string    `json:"translation"`
+	Reference   Reference `json:"reference"`
+	Sentences   []string  `json:"sentences"`
+}
+
+type PassageVerse struct {
+	Chapter int    `json:"chapter"`
+	Verse   int    `json:"verse"`
+	Text    string `json:"text"`
+}
+
+type PassageResponse struct {
+	Translation    string         `json:"translation"`
+	BookNumber     int            `json:"book_number"`
+	BookTitle      string         `json:"book_title"`
+	BookTitleShort string         `json:"book_title_short"`
+	From           string         `json:"from"`
+	To             string         `json:"to"`
+	Verses         []PassageVerse `json:"verses"`
+}
+
+// Maximum number of references accepted in one batch request
+const maxBatchSize = 100
+
+// Optional response fields selectable via ?include=a,b
+var supportedIncludes = map[string]bool{
+	"index":    true,
+	"locale":   true,
+	"rowid":    true,
+	"translit": true,
+}
+
+// Parse the include query parameter into a set of optional fields
+func parseIncludes(r *http.Request) (map[string]bool, error) {
+	includes := make(map[string]bool)
+	value := r.URL.Query().Get("include")
+	if value == "" {
+		return includes, nil
+	}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if !supportedIncludes[name] {
+			return nil, fmt.Errorf("Unknown include '%s'", name)
+		}
+		includes[name] = true
+	}
+	return includes, nil
+}
+
+// Populate the optional fields requested via include
+func applyIncludes(ctx context.Context, store VerseStore, verse *VerseResponse, includes map[string]bool) error {
+	if includes["index"] {
+		index, err := store.GlobalIndex(ctx, Reference{Book: verse.BookNumber, Chapter: verse.Chapter, Verse: verse.Verse})
+		if err != nil {
+			return err
+		}
+		verse.GlobalIndex = &index
+	}
+	if includes["rowid"] {
+		rowID := verse.rowID
+		verse.RowID = &rowID
+	}
+	if includes["locale"] {
+		dbMutex.RLock()
+		verse.Language = translationLanguages[verse.Translation]
+		verse.Direction = translationMetadata[verse.Translation].Direction
+		dbMutex.RUnlock()
+	}
+	if includes["translit"] {
+		text, err := store.Transliteration(ctx, Reference{Book: verse.BookNumber, Chapter: verse.Chapter, Verse: verse.Verse})
+		if err != nil {
+			return err
+		}
+		verse.Transliteration = text
+	}
+	return nil
+}
+
+// Compute the 1-based position of a verse in canonical order
+func globalIndex(ctx context.Context, db *sql.DB, book, chapter, verse int) (int, error) {
+	var index int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM verses
+		WHERE book_number < ?
+			OR (book_number = ? AND chapter < ?)
+			OR (book_number = ? AND chapter = ? AND verse <= ?)
+	`, book, book, chapter, book, chapter, verse).Scan(&index)
+	return index, err
+}
+
+// Fetch several verses at once; the result follows the order of refs,
+// with nil entries for references that do not exist.
+func fetchVerses(ctx context.Context, db *sql.DB, translationName string, refs []Reference) ([]*VerseResponse, error) {
+	results := make([]*VerseResponse, len(refs))
+	if len(refs) == 0 {
+		return results, nil
+	}
+
+	placeholders := make([]string, len(refs))
+	args := make([]interface{}, 0, len(refs)*3)
+	for i, ref := range refs {
+		placeholders[i] = "(?, ?, ?)"
+		args = append(args, ref.Book, ref.Chapter, ref.Verse)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT v.rowid, v.book_number, v.chapter, v.verse, v.text, b.short_name, b.long_name
+		FROM verses v
+		JOIN books b ON v.book_number = b.book_number
+		WHERE (v.book_number, v.chapter, v.verse) IN (VALUES %s)
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[Reference]*VerseResponse, len(refs))
+	for rows.Next() {
+		var verse VerseResponse
+		var rawText string
+		if err := rows.Scan(&verse.rowID, &verse.BookNumber, &verse.Chapter, &verse.Verse, &rawText, &verse.BookTitleShort, &verse.BookTitle); err != nil {
+			return nil, err
+		}
+		verse.Text = clearText(rawText)
+		verse.rawText = rawText
+		verse.Translation = translationName
+		found[Reference{Book: verse.BookNumber, Chapter: verse.Chapter, Verse: verse.Verse}] = &verse
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, ref := range refs {
+		results[i] = found[ref]
+	}
+	return results, nil
+}
+
+// Get a single verse handler
+func getVerseHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "ssml" {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unsupported format '%s'", format), http.StatusBadRequest)
+		return
+	}
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	transforms, err := parseTransforms(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strongs, err := parseStrongs(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fallback := r.URL.Query().Get("fallback")
+	if _, exists := translations[fallback]; fallback != "" && !exists {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Fallback translation '%s' not found", fallback), http.StatusBadRequest)
+		return
+	}
+	canonical, err := parseCanonical(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	footnotes, err := parseFootnotes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if footnotes && format == "ssml" {
+		respondWithError(w, errCodeInvalidParameter, "Parameter 'footnotes' cannot be combined with format=ssml", http.StatusBadRequest)
+		return
+	}
+	// The path reference uses canonical numbering; look up each translation's own
+	stored := func(name string) Reference {
+		if canonical {
+			return canonicalReference(name, ref)
+		}
+		return ref
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := store.GetVerses(r.Context(), []Reference{stored(translationName)})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	verse := verses[0]
+
+	// Versification gaps: try the same reference in the fallback translation
+	if verse == nil && fallback != "" && fallback != translationName {
+		store, ok = lookupStore(w, fallback)
+		if !ok {
+			return
+		}
+		verses, err = store.GetVerses(r.Context(), []Reference{stored(fallback)})
+		if err != nil {
+			logQueryError(fallback, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+			return
+		}
+		verse = verses[0]
+		if verse != nil {
+			verse.FallbackUsed = true
+		}
+	}
+	if verse == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+	var notes []Footnote
+	if footnotes {
+		var raw string
+		raw, notes = extractFootnotes(verse.rawText, 0, 0)
+		verse.Text = renderText(raw, verse.BookNumber, transforms, strongs)
+	} else if transforms != nil || strongs != strongsStrip {
+		verse.Text = renderText(verse.rawText, verse.BookNumber, transforms, strongs)
+	}
+
+	if format == "ssml" {
+		respondWithSSML(w, verse.Text)
+		return
+	}
+
+	if err := applyIncludes(r.Context(), store, verse, includes); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+
+	if footnotes {
+		writeJSON(w, http.StatusOK, VerseFootnotesResponse{VerseResponse: *verse, Footnotes: notes})
+		return
+	}
+	writeJSON(w, http.StatusOK, verse)
+}
+
+// Get a verse split into sentence fragments handler
+func sentencesHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	clauses := true
+	if value := r.URL.Query().Get("clauses"); value != "" {
+		clauses, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid clauses value", http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := store.GetVerses(r.Context(), []Reference{ref})
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+	if verses[0] == nil {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Verse %d:%d:%d not found", ref.Book, ref.Chapter, ref.Verse), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SentencesResponse{
+		Translation: translationName,
+		Reference:   ref,
+		Sentences:   splitSentences(plainText(verses[0].Text), clauses),
+	})
+}
+
+// Batch verse fetch handler
+func batchVersesHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var refs []Reference
+	if !decodeJSONBody(w, r, &refs, "Request body must be a JSON array of {book, chapter, verse}") {
+		return
+	}
+	if len(refs) == 0 {
+		respondWithError(w, errCodeInvalidBody, "Request body must contain at least one reference", http.StatusBadRequest)
+		return
+	}
+	if len(refs) > maxBatchSize {
+		respondWithError(w, errCodeInvalidBody, fmt.Sprintf("Batch size exceeds the maximum of %d references", maxBatchSize), http.StatusBadRequest)
+		return
+	}
+	for i, ref := range refs {
+		if ref.Book < 1 || ref.Chapter < 1 || ref.Verse < 1 {
+			respondWithError(w, errCodeInvalidReference, fmt.Sprintf("Invalid reference at index %d", i), http.StatusBadRequest)
+			return
+		}
+	}
+
+	respondWithVerses(w, r, translationName, refs, includes)
+}
+
+// Get several verses by a comma-separated reference list handler
+func getVersesHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value := r.URL.Query().Get("refs")
+	if value == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameter 'refs' is required", http.StatusBadRequest)
+		return
+	}
+	refs, err := parseReferenceList(translationName, value, maxBatchSize)
+	if err != nil {
+		respondWithError(w, errCodeInvalidReference, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondWithVerses(w, r, translationName, refs, includes)
+}
+
+// Parse a reference list such as "500:3:16,230:23:1" (book:chapter:verse,
+// where the book is a number or a name) of at most limit entries
+func parseReferenceList(translationName, value string, limit int) ([]Reference, error) {
+	if value == "" {
+		return nil, nil
+	}
+	items := strings.Split(value, ",")
+	if len(items) > limit {
+		return nil, fmt.Errorf("At most %d references can be given", limit)
+	}
+
+	refs := make([]Reference, 0, len(items))
+	for _, item := range items {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("Invalid reference '%s', expected book:chapter:verse", item)
+		}
+		book, err := parseBook(translationName, parts[0])
+		if err != nil {
+			return nil, err
+		}
+		chapter, chapterErr := strconv.Atoi(parts[1])
+		verse, verseErr := strconv.Atoi(parts[2])
+		if chapterErr != nil || verseErr != nil || chapter < 1 || verse < 1 {
+			return nil, fmt.Errorf("Invalid reference '%s', expected book:chapter:verse", item)
+		}
+		refs = append(refs, Reference{Book: book, Chapter: chapter, Verse: verse})
+	}
+	return refs, nil
+}
+
+// Respond with the verses for refs in their given order, null for misses
+func respondWithVerses(w http.ResponseWriter, r *http.Request, translationName string, refs []Reference, includes map[string]bool) {
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	verses, err := store.GetVerses(r.Context(), refs)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+		return
+	}
+
+	for _, verse := range verses {
+		if verse == nil {
+			continue
+		}
+		if err := applyIncludes(r.Context(), store, verse, includes); err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verses", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, verses)
+}
+
+// Upper bound on the verses a single passage request may return
+const maxPassageVerses = 500
+
+// Parse a chapter:verse position such as 3:16
+func parsePosition(value string) (chapter, verse int, err error) {
+	chapterPart, versePart, found := strings.Cut(value, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("Invalid position '%s', expected chapter:verse", value)
+	}
+	chapter, err = strconv.Atoi(chapterPart)
+	if err != nil || chapter < 1 {
+		return 0, 0, fmt.Errorf("Invalid position '%s', expected chapter:verse", value)
+	}
+	verse, err = strconv.Atoi(versePart)
+	if err != nil || verse < 1 {
+		return 0, 0, fmt.Errorf("Invalid position '%s', expected chapter:verse", value)
+	}
+	return chapter, verse, nil
+}
+
+// Get the verses of a passage that may span chapters handler, e.g. ?from=3:16&to=4:2
+func passageHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+	ref, err := pathReference(r)
+	if err != nil {
+		respondWithReferenceError(w, err)
+		return
+	}
+
+	params := r.URL.Query()
+	if params.Get("from") == "" || params.Get("to") == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameters 'from' and 'to' are required", http.StatusBadRequest)
+		return
+	}
+	fromChapter, fromVerse, err := parsePosition(params.Get("from"))
+	if err != nil {
+		respondWithError(w, errCodeInvalidReference, err.Error(), http.StatusBadRequest)
+		return
+	}
+	toChapter, toVerse, err := parsePosition(params.Get("to"))
+	if err != nil {
+		respondWithError(w, errCodeInvalidReference, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if toChapter < fromChapter || (toChapter == fromChapter && toVerse < fromVerse) {
+		respondWithError(w, errCodeInvalidReference, "Position 'from' must not come after 'to'", http.StatusBadRequest)
+		return
+	}
+	descending, err := parseOrder(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := params.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		respondWithError(w, errCodeInvalidParameter, fmt.Sprintf("Unsupported format '%s'", format), http.StatusBadRequest)
+		return
+	}
+
+	db, ok := lookupDatabase(w, translationName)
+	if !ok {
+		return
+	}
+
+	// One verse past the cap is enough to tell the passage is too long
+	verses, err := fetchPassage(r.Context(), db, ref.Book, fromChapter, fromVerse, toChapter, toVerse, descending, maxPassageVerses+1)
+	if err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve passage", http.StatusInternalServerError)
+		return
+	}
+	if len(verses) == 0 {
+		respondWithError(w, errCodeVerseNotFound, fmt.Sprintf("Passage %d:%d:%d-%d:%d not found", ref.Book, fromChapter, fromVerse, toChapter, toVerse), http.StatusNotFound)
+		return
+	}
+	if len(verses) > maxPassageVerses {
+		respondWithError(w, errCodeInvalidReference, fmt.Sprintf("Passage exceeds the maximum of %d verses", maxPassageVerses), http.StatusBadRequest)
+		return
+	}
+
+	if format == "csv" {
+		rows := make([][]string, len(verses))
+		for i, verse := range verses {
+			rows[i] = csvRow(ref.Book, verse.Chapter, verse.Verse, verse.Text)
+		}
+		respondWithCSV(w, fmt.Sprintf("%s-%d-%d.%d-%d.%d.csv", translationName, ref.Book, fromChapter, fromVerse, toChapter, toVerse), rows)
+		return
+	}
+
+	response := PassageResponse{
+		Translation: translationName,
+		BookNumber:  ref.Book,
+		From:        fmt.Sprintf("%d:%d", fromChapter, fromVerse),
+		To:          fmt.Sprintf("%d:%d", toChapter, toVerse),
+		Verses:      verses,
+	}
+	err = db.QueryRowContext(r.Context(), "SELECT long_name, short_name FROM books WHERE book_number = ?", ref.Book).Scan(&response.BookTitle, &response.BookTitleShort)
+	if err != nil && err != sql.ErrNoRows {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve passage", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Fetch the cleaned verses of one book between two chapter:verse positions,
+// inclusive, last to first when descending; at most limit verses are read
+// (negative for all)
+func fetchPassage(ctx context.Context, db *sql.DB, book, fromChapter, fromVerse, toChapter, toVerse int, descending bool, limit int) ([]PassageVerse, error) {
+	order := "chapter, verse"
+	if descending {
+		order = "chapter DESC, verse DESC"
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT chapter, verse, text FROM verses
+		WHERE book_number = ?
+			AND (chapter > ? OR (chapter = ? AND verse >= ?))
+			AND (chapter < ? OR (chapter = ? AND verse <= ?))
+		ORDER BY `+order+`
+		LIMIT ?`, book, fromChapter, fromChapter, fromVerse, toChapter, toChapter, toVerse, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verses := make([]PassageVerse, 0)
+	for rows.Next() {
+		var verse PassageVerse
+		var rawText string
+		if err := rows.Scan(&verse.Chapter, &verse.Verse, &rawText); err != nil {
+			return nil, err
+		}
+		verse.Text = clearText(rawText)
+		verses = append(verses, verse)
+	}
+	return verses, rows.Err()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestPassageVerseCap(t *testing.T) {
+	var verses []testVerse
+	for chapter := 1; chapter <= 3; chapter++ {
+		for verse := 1; verse <= 200; verse++ {
+			verses = append(verses, testVerse{20, chapter, verse, fmt.Sprintf("Verse %d:%d.", chapter, verse)})
+		}
+	}
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", verses)})
+
+	// Exactly the cap: 200 + 200 + 100 verses
+	if rec := serve(http.MethodGet, "/passage/TEST/20?from=1:1&to=3:100", ""); rec.Code != http.StatusOK {
+		t.Fatalf("passage of %d verses = %d, want 200 (body %s)", maxPassageVerses, rec.Code, rec.Body)
+	}
+	assertError(t, serve(http.MethodGet, "/passage/TEST/20?from=1:1&to=3:101", ""), http.StatusBadRequest, errCodeInvalidReference)
+	assertError(t, serve(http.MethodGet, "/passage/TEST/20?from=1:1&to=3:200", ""), http.StatusBadRequest, errCodeInvalidReference)
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+type VersificationDiff struct {
+	BookNumber int `json:"book_number"`
+	Chapter    int `json:"chapter"`
+	VersesA    int `json:"verses_a"`
+	VersesB    int `json:"verses_b"`
+}
+
+type VersificationDiffResponse struct {
+	A           string              `json:"a"`
+	B           string              `json:"b"`
+	BookNumber  int                 `json:"book_number,omitempty"`
+	Differences []VersificationDiff `json:"differences"`
+}
+
+// A book and chapter, used to line up chapters across translations
+type chapterKey struct {
+	book    int
+	chapter int
+}
+
+// Count the verses of every chapter, optionally limited to one book (0 for all)
+func chapterVerseCounts(ctx context.Context, db *sql.DB, book int) (map[chapterKey]int, error) {
+	query := "SELECT book_number, chapter, COUNT(*) FROM verses GROUP BY book_number, chapter"
+	var args []interface{}
+	if book > 0 {
+		query = "SELECT book_number, chapter, COUNT(*) FROM verses WHERE book_number = ? GROUP BY book_number, chapter"
+		args = append(args, book)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[chapterKey]int)
+	for rows.Next() {
+		var key chapterKey
+		var count int
+		if err := rows.Scan(&key.book, &key.chapter, &count); err != nil {
+			return nil, err
+		}
+		counts[key] = count
+	}
+	return counts, rows.Err()
+}
+
+// The chapters of a count map in canonical order
+func sortedChapters(counts map[chapterKey]int) []chapterKey {
+	keys := make([]chapterKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].book != keys[j].book {
+			return keys[i].book < keys[j].book
+		}
+		return keys[i].chapter < keys[j].chapter
+	})
+	return keys
+}
+
+// Report chapters whose verse counts differ between two translations handler.
+// Differing counts are a proxy for versification differences; a chapter
+// missing from one translation counts as 0 verses there.
+func versificationDiffHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	nameA, nameB := params.Get("a"), params.Get("b")
+	if nameA == "" || nameB == "" {
+		respondWithError(w, errCodeInvalidParameter, "Query parameters 'a' and 'b' are required", http.StatusBadRequest)
+		return
+	}
+
+	book := 0
+	if value := params.Get("book"); value != "" {
+		var err error
+		book, err = parseBook(nameA, value)
+		if err != nil {
+			respondWithReferenceError(w, err)
+			return
+		}
+	}
+
+	dbA, ok := lookupDatabase(w, nameA)
+	if !ok {
+		return
+	}
+	dbB, ok := lookupDatabase(w, nameB)
+	if !ok {
+		return
+	}
+
+	countsA, err := chapterVerseCounts(r.Context(), dbA, book)
+	if err != nil {
+		logQueryError(nameA, err)
+		respondWithError(w, errCodeInternal, "Failed to compare versification", http.StatusInternalServerError)
+		return
+	}
+	countsB, err := chapterVerseCounts(r.Context(), dbB, book)
+	if err != nil {
+		logQueryError(nameB, err)
+		respondWithError(w, errCodeInternal, "Failed to compare versification", http.StatusInternalServerError)
+		return
+	}
+
+	if book > 0 && len(countsA) == 0 && len(countsB) == 0 {
+		respondWithError(w, errCodeBookNotFound, fmt.Sprintf("Book %d not found", book), http.StatusNotFound)
+		return
+	}
+
+	keys := make(map[chapterKey]bool, len(countsA))
+	for key := range countsA {
+		keys[key] = true
+	}
+	for key := range countsB {
+		keys[key] = true
+	}
+
+	differences := make([]VersificationDiff, 0)
+	for key := range keys {
+		if countsA[key] != countsB[key] {
+			differences = append(differences, VersificationDiff{
+				BookNumber: key.book,
+				Chapter:    key.chapter,
+				VersesA:    countsA[key],
+				VersesB:    countsB[key],
+			})
+		}
+	}
+	sort.Slice(differences, func(i, j int) bool {
+		if differences[i].BookNumber != differences[j].BookNumber {
+			return differences[i].BookNumber < differences[j].BookNumber
+		}
+		return differences[i].Chapter < differences[j].Chapter
+	})
+
+	writeJSON(w, http.StatusOK, VersificationDiffResponse{
+		A:           nameA,
+		B:           nameB,
+		BookNumber:  book,
+		Differences: differences,
+	})
+}
+
+// Verses numbered differently in a translation than in the canonical (KJV)
+// scheme: canonical chapter:from-to of a book is stored from
+// targetChapter:targetFrom onwards
+type versificationRule struct {
+	book, chapter, from, to   int
+	targetChapter, targetFrom int
+}
+
+// Versification mappings per translation, applied with ?canonical=true;
+// a translation without an entry uses canonical numbering
+var versificationMaps = map[string][]versificationRule{
+	"RST": rstVersification,
+}
+
+// Map a canonical reference to the one a translation stores it under
+func canonicalReference(translationName string, ref Reference) Reference {
+	for _, rule := range versificationMaps[translationName] {
+		if rule.book == ref.Book && rule.chapter == ref.Chapter && ref.Verse >= rule.from && ref.Verse <= rule.to {
+			return Reference{Book: ref.Book, Chapter: rule.targetChapter, Verse: rule.targetFrom + ref.Verse - rule.from}
+		}
+	}
+	return ref
+}
+
+// Parse ?canonical (default false)
+func parseCanonical(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("canonical")
+	if value == "" {
+		return false, nil
+	}
+	canonical, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid canonical value")
+	}
+	return canonical, nil
+}
+
+// Synodal numbering: Psalms follow the Septuagint (9-10, 114-115 merged,
+// 116 and 147 split) and count superscriptions as verses; chapter
+// boundaries differ in a few other books. Psalm offsets were checked by
+// matching Strong's numbers between the KJV and RST modules.
+var rstVersification = []versificationRule{
+	// Numbers
+	{40, 12, 16, 16, 13, 1},
+	{40, 13, 1, 33, 13, 2},
+	{40, 29, 40, 40, 30, 1},
+	{40, 30, 1, 16, 30, 2},
+	// Joshua
+	{60, 6, 1, 1, 5, 16},
+	{60, 6, 2, 27, 6, 1},
+	// 1 Samuel
+	{90, 23, 29, 29, 24, 1},
+	{90, 24, 1, 22, 24, 2},
+	// Job
+	{220, 40, 1, 5, 39, 31},
+	{220, 40, 6, 24, 40, 1},
+	{220, 41, 1, 8, 40, 20},
+	{220, 41, 9, 34, 41, 1},
+	// Psalms
+	{230, 3, 1, 8, 3, 2},
+	{230, 4, 1, 8, 4, 2},
+	{230, 5, 1, 12, 5, 2},
+	{230, 6, 1, 10, 6, 2},
+	{230, 7, 1, 17, 7, 2},
+	{230, 8, 1, 9, 8, 2},
+	{230, 9, 1, 20, 9, 2},
+	{230, 10, 1, 18, 9, 22},
+	{230, 11, 1, 7, 10, 1},
+	{230, 12, 1, 8, 11, 2},
+	{230, 13, 1, 5, 12, 2},
+	{230, 13, 6, 6, 12, 6},
+	{230, 14, 1, 7, 13, 1},
+	{230, 15, 1, 5, 14, 1},
+	{230, 16, 1, 11, 15, 1},
+	{230, 17, 1, 15, 16, 1},
+	{230, 18, 1, 50, 17, 2},
+	{230, 19, 1, 14, 18, 2},
+	{230, 20, 1, 9, 19, 2},
+	{230, 21, 1, 13, 20, 2},
+	{230, 22, 1, 31, 21, 2},
+	{230, 23, 1, 6, 22, 1},
+	{230, 24, 1, 10, 23, 1},
+	{230, 25, 1, 22, 24, 1},
+	{230, 26, 1, 12, 25, 1},
+	{230, 27, 1, 14, 26, 1},
+	{230, 28, 1, 9, 27, 1},
+	{230, 29, 1, 11, 28, 1},
+	{230, 30, 1, 12, 29, 2},
+	{230, 31, 1, 24, 30, 2},
+	{230, 32, 1, 11, 31, 1},
+	{230, 33, 1, 22, 32, 1},
+	{230, 34, 1, 22, 33, 2},
+	{230, 35, 1, 28, 34, 1},
+	{230, 36, 1, 12, 35, 2},
+	{230, 37, 1, 40, 36, 1},
+	{230, 38, 1, 22, 37, 2},
+	{230, 39, 1, 13, 38, 2},
+	{230, 40, 1, 17, 39, 2},
+	{230, 41, 1, 13, 40, 2},
+	{230, 42, 1, 11, 41, 2},
+	{230, 43, 1, 5, 42, 1},
+	{230, 44, 1, 26, 43, 2},
+	{230, 45, 1, 17, 44, 2},
+	{230, 46, 1, 11, 45, 2},
+	{230, 47, 1, 9, 46, 2},
+	{230, 48, 1, 14, 47, 2},
+	{230, 49, 1, 20, 48, 2},
+	{230, 50, 1, 23, 49, 1},
+	{230, 51, 1, 19, 50, 3},
+	{230, 52, 1, 9, 51, 3},
+	{230, 53, 1, 6, 52, 2},
+	{230, 54, 1, 7, 53, 3},
+	{230, 55, 1, 23, 54, 2},
+	{230, 56, 1, 13, 55, 2},
+	{230, 57, 1, 11, 56, 2},
+	{230, 58, 1, 11, 57, 2},
+	{230, 59, 1, 17, 58, 2},
+	{230, 60, 1, 12, 59, 3},
+	{230, 61, 1, 8, 60, 2},
+	{230, 62, 1, 12, 61, 2},
+	{230, 63, 1, 11, 62, 2},
+	{230, 64, 1, 10, 63, 2},
+	{230, 65, 1, 13, 64, 2},
+	{230, 66, 1, 20, 65, 1},
+	{230, 67, 1, 7, 66, 2},
+	{230, 68, 1, 35, 67, 2},
+	{230, 69, 1, 36, 68, 2},
+	{230, 70, 1, 5, 69, 2},
+	{230, 71, 1, 24, 70, 1},
+	{230, 72, 1, 20, 71, 1},
+	{230, 73, 1, 28, 72, 1},
+	{230, 74, 1, 23, 73, 1},
+	{230, 75, 1, 10, 74, 2},
+	{230, 76, 1, 12, 75, 2},
+	{230, 77, 1, 20, 76, 2},
+	{230, 78, 1, 72, 77, 1},
+	{230, 79, 1, 13, 78, 1},
+	{230, 80, 1, 19, 79, 2},
+	{230, 81, 1, 16, 80, 2},
+	{230, 82, 1, 8, 81, 1},
+	{230, 83, 1, 18, 82, 2},
+	{230, 84, 1, 12, 83, 2},
+	{230, 85, 1, 13, 84, 2},
+	{230, 86, 1, 17, 85, 1},
+	{230, 87, 1, 7, 86, 1},
+	{230, 88, 1, 18, 87, 2},
+	{230, 89, 1, 52, 88, 2},
+	{230, 90, 1, 1, 89, 1},
+	{230, 90, 2, 5, 89, 3},
+	{230, 90, 6, 17, 89, 6},
+	{230, 91, 1, 16, 90, 1},
+	{230, 92, 1, 15, 91, 2},
+	{230, 93, 1, 5, 92, 1},
+	{230, 94, 1, 23, 93, 1},
+	{230, 95, 1, 11, 94, 1},
+	{230, 96, 1, 13, 95, 1},
+	{230, 97, 1, 12, 96, 1},
+	{230, 98, 1, 9, 97, 1},
+	{230, 99, 1, 9, 98, 1},
+	{230, 100, 1, 5, 99, 1},
+	{230, 101, 1, 8, 100, 1},
+	{230, 102, 1, 28, 101, 2},
+	{230, 103, 1, 22, 102, 1},
+	{230, 104, 1, 35, 103, 1},
+	{230, 105, 1, 45, 104, 1},
+	{230, 106, 1, 48, 105, 1},
+	{230, 107, 1, 43, 106, 1},
+	{230, 108, 1, 13, 107, 2},
+	{230, 109, 1, 31, 108, 1},
+	{230, 110, 1, 7, 109, 1},
+	{230, 111, 1, 10, 110, 1},
+	{230, 112, 1, 10, 111, 1},
+	{230, 113, 1, 9, 112, 1},
+	{230, 114, 1, 8, 113, 1},
+	{230, 115, 1, 18, 113, 9},
+	{230, 116, 1, 9, 114, 1},
+	{230, 116, 10, 19, 115, 1},
+	{230, 117, 1, 2, 116, 1},
+	{230, 118, 1, 29, 117, 1},
+	{230, 119, 1, 176, 118, 1},
+	{230, 120, 1, 7, 119, 1},
+	{230, 121, 1, 8, 120, 1},
+	{230, 122, 1, 9, 121, 1},
+	{230, 123, 1, 4, 122, 1},
+	{230, 124, 1, 8, 123, 1},
+	{230, 125, 1, 5, 124, 1},
+	{230, 126, 1, 6, 125, 1},
+	{230, 127, 1, 5, 126, 1},
+	{230, 128, 1, 6, 127, 1},
+	{230, 129, 1, 8, 128, 1},
+	{230, 130, 1, 8, 129, 1},
+	{230, 131, 1, 3, 130, 1},
+	{230, 132, 1, 18, 131, 1},
+	{230, 133, 1, 3, 132, 1},
+	{230, 134, 1, 3, 133, 1},
+	{230, 135, 1, 21, 134, 1},
+	{230, 136, 1, 26, 135, 1},
+	{230, 137, 1, 9, 136, 1},
+	{230, 138, 1, 8, 137, 1},
+	{230, 139, 1, 24, 138, 1},
+	{230, 140, 1, 13, 139, 2},
+	{230, 141, 1, 10, 140, 1},
+	{230, 142, 1, 7, 141, 1},
+	{230, 143, 1, 12, 142, 1},
+	{230, 144, 1, 15, 143, 1},
+	{230, 145, 1, 21, 144, 1},
+	{230, 146, 1, 10, 145, 1},
+	{230, 147, 1, 11, 146, 1},
+	{230, 147, 12, 20, 147, 1},
+	// Ecclesiastes
+	{250, 5, 1, 1, 4, 17},
+	{250, 5, 2, 20, 5, 1},
+	// Song of Songs
+	{260, 6, 13, 13, 7, 1},
+	{260, 7, 1, 13, 7, 2},
+	// Daniel
+	{340, 4, 1, 3, 3, 31},
+	{340, 4, 4, 37, 4, 1},
+	// Hosea
+	{350, 13, 16, 16, 14, 1},
+	{350, 14, 1, 9, 14, 2},
+	// Jonah
+	{390, 1, 17, 17, 2, 1},
+	{390, 2, 1, 10, 2, 2},
+	// Romans
+	{520, 16, 25, 27, 14, 24},
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type VerseOfTheDayResponse struct {
+	Date   string `json:"date"`
+	Source string `json:"source"`
+	VerseResponse
+}
+
+// Curated verse-of-the-day references (env VOTD_FILE), cycled through by
+// date; when empty the verse is picked by hashing the date
+var votdReferences []Reference
+
+// Read a curated list: one book:chapter:verse reference per line (the book
+// a number or a name), with blank lines and # comments ignored
+func loadVOTDFile(path string) ([]Reference, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var refs []Reference
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parsed, err := parseReferenceList("", text, 1)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		refs = append(refs, parsed[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("no references in %s", path)
+	}
+	return refs, nil
+}
+
+// Warn about curated references missing from any loaded translation
+func validateVOTDReferences(refs []Reference) {
+	dbMutex.RLock()
+	databases := make(map[string]*sql.DB, len(dbPool))
+	names := make([]string, 0, len(dbPool))
+	for name, db := range dbPool {
+		databases[name] = db
+		names = append(names, name)
+	}
+	dbMutex.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		verses, err := fetchVerses(context.Background(), databases[name], name, refs)
+		if err != nil {
+			logQueryError(name, err)
+			continue
+		}
+		for i, verse := range verses {
+			if verse == nil {
+				log.Printf("Warning: VOTD_FILE reference %d:%d:%d not found in %s", refs[i].Book, refs[i].Chapter, refs[i].Verse, name)
+			}
+		}
+	}
+}
+
+// Get the verse of the day handler: the curated list entry for the date when
+// one is configured, otherwise a verse picked by hashing the date
+func verseOfTheDayHandler(w http.ResponseWriter, r *http.Request) {
+	translationName := r.PathValue("translation")
+
+	date := time.Now().UTC().Truncate(24 * time.Hour)
+	if value := r.URL.Query().Get("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			respondWithError(w, errCodeInvalidParameter, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		date = parsed
+	} else {
+		// Today's verse changes at midnight; only dated requests are stable
+		w.Header().Set("Cache-Control", randomCacheControl)
+	}
+
+	includes, err := parseIncludes(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	transforms, err := parseTransforms(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strongs, err := parseStrongs(r)
+	if err != nil {
+		respondWithError(w, errCodeInvalidParameter, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	store, ok := lookupStore(w, translationName)
+	if !ok {
+		return
+	}
+
+	source := "curated"
+	var verse *VerseResponse
+	if len(votdReferences) > 0 {
+		// Days since the Unix epoch index the list, wrapping around at its end
+		days := int(date.Unix() / 86400)
+		index := ((days % len(votdReferences)) + len(votdReferences)) % len(votdReferences)
+		verses, err := store.GetVerses(r.Context(), votdReferences[index:index+1])
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+			return
+		}
+		verse = verses[0]
+	} else {
+		source = "random"
+		verse, err = seededVerse(r.Context(), store, translationName, date.Format("2006-01-02"))
+		if err != nil {
+			logQueryError(translationName, err)
+			respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+			return
+		}
+	}
+	if verse == nil {
+		respondWithError(w, errCodeVerseNotFound, "Verse of the day not found in this translation", http.StatusNotFound)
+		return
+	}
+	if transforms != nil || strongs != strongsStrip {
+		verse.Text = renderText(verse.rawText, verse.BookNumber, transforms, strongs)
+	}
+
+	if err := applyIncludes(r.Context(), store, verse, includes); err != nil {
+		logQueryError(translationName, err)
+		respondWithError(w, errCodeInternal, "Failed to retrieve verse", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, VerseOfTheDayResponse{
+		Date:          date.Format("2006-01-02"),
+		Source:        source,
+		VerseResponse: *verse,
+	})
+}
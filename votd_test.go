@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestVerseOfTheDayCacheControl(t *testing.T) {
+	loadTestTranslations(t, map[string]string{"TEST": newTestDatabase(t, "en", testGenesis)})
+
+	rec := serve(http.MethodGet, "/verse-of-the-day/TEST", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != randomCacheControl {
+		t.Errorf("today's Cache-Control = %q, want %q", got, randomCacheControl)
+	}
+
+	rec = serve(http.MethodGet, "/verse-of-the-day/TEST?date=2026-10-14", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d (body %s)", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "" {
+		t.Errorf("dated Cache-Control = %q, want none", got)
+	}
+}
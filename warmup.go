@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// Preload the verse-count and TOC caches after the databases open (WARM_UP),
+// so the first requests do not pay for building them
+var warmUpEnabled = false
+
+// Fill the caches of every loaded translation in the background, one
+// goroutine per translation
+func warmUpCaches() {
+	dbMutex.RLock()
+	databases := make(map[string]*sql.DB, len(dbPool))
+	for name, db := range dbPool {
+		databases[name] = db
+	}
+	dbMutex.RUnlock()
+
+	for name, db := range databases {
+		go func(name string, db *sql.DB) {
+			start := time.Now()
+			ctx := context.Background()
+			if _, err := getBookVerseCounts(ctx, name, db); err != nil {
+				log.Printf("Warm-up failed for %s: %v", name, err)
+				return
+			}
+			// Also fills the outline cache the TOC is built from
+			if _, err := getTOC(ctx, name, db); err != nil {
+				log.Printf("Warm-up failed for %s: %v", name, err)
+				return
+			}
+			log.Printf("Warmed up caches for %s in %v", name, time.Since(start).Round(time.Millisecond))
+		}(name, db)
+	}
+}